@@ -0,0 +1,211 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Batcher accumulates items in a ConcurrentRingOf and flushes them in batches,
+// when size items are buffered or every interval, whichever comes first.
+// Items stay in the buffer until flushed successfully, when the buffer reaches
+// its maxSize, new items are discarded through onDiscards.
+// It is thread-safe(goroutine-safe).
+type Batcher[T any] struct {
+	discards   uint64 // added after Close or dropped by the final flush, first for 64-bit alignment
+	rb         *ConcurrentRingOf[T]
+	size       int
+	interval   time.Duration
+	flush      func(ctx context.Context, items []T) error
+	backoff    func(attempt int, err error) (time.Duration, bool)
+	after      func(d time.Duration) <-chan time.Time
+	onDiscards func(T)
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	kick    chan struct{}
+	closing chan struct{}
+	stopped chan struct{}
+	start   sync.Once
+	stop    sync.Once
+}
+
+// NewBatcher creates a Batcher that calls flush with at most size items,
+// at least every interval while there are buffered items.
+// maxBufferSize limits the buffered items, 0 means unbounded.
+func NewBatcher[T any](size int, interval time.Duration, maxBufferSize int,
+	flush func(ctx context.Context, items []T) error) *Batcher[T] {
+	if size < 1 {
+		size = 1
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Batcher[T]{
+		rb:       NewConcurrentRingOf[T](size, maxBufferSize),
+		size:     size,
+		interval: interval,
+		flush:    flush,
+		after:    time.After,
+		ctx:      ctx,
+		cancel:   cancel,
+		kick:     make(chan struct{}, 1),
+		closing:  make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// SetBackoff registers the retry hook called after a failed flush with the attempt number (from 1)
+// and the error. It returns how long to wait before retrying, or false to give up,
+// the items are kept and retried on the next flush. Without it, failed flushes are not retried.
+// It must be called before the first Add.
+func (b *Batcher[T]) SetBackoff(fn func(attempt int, err error) (time.Duration, bool)) {
+	if fn != nil {
+		b.backoff = fn
+	}
+}
+
+// SetOnDiscards registers the callback for items discarded at maxSize,
+// added after Close, or still undelivered by the final flush.
+// It is called without the lock held, so it may call the Batcher's methods.
+// It must be called before the first Add.
+func (b *Batcher[T]) SetOnDiscards(fn func(T)) {
+	if fn != nil {
+		b.onDiscards = fn
+	}
+}
+
+// SetClock replaces time.After, used for the flush interval and the backoff, useful for tests.
+// There is at most one pending interval timer, it is only renewed after it fires.
+// It must be called before the first Add.
+func (b *Batcher[T]) SetClock(after func(d time.Duration) <-chan time.Time) {
+	if after != nil {
+		b.after = after
+	}
+}
+
+// Add buffers v, and triggers a flush when size items are buffered.
+func (b *Batcher[T]) Add(v T) {
+	b.start.Do(func() {
+		go b.loop()
+	})
+
+	stored, err := b.rb.write(v, false)
+	if err != nil {
+		atomic.AddUint64(&b.discards, 1)
+	}
+	if !stored {
+		if b.onDiscards != nil {
+			b.onDiscards(v)
+		}
+		return
+	}
+
+	if b.rb.Len() >= b.size {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close stops the Batcher and flushes the remaining items.
+// Items that cannot be delivered before ctx is done are discarded through onDiscards.
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	b.rb.Close()
+	b.stop.Do(func() {
+		close(b.closing)
+	})
+	b.start.Do(func() {
+		close(b.stopped)
+	})
+
+	select {
+	case <-b.stopped:
+	case <-ctx.Done():
+		b.cancel()
+		<-b.stopped
+	}
+	b.cancel()
+
+	if err := b.flushAll(ctx); err != nil {
+		for {
+			v, rerr := b.rb.Read()
+			if rerr != nil {
+				break
+			}
+			atomic.AddUint64(&b.discards, 1)
+			if b.onDiscards != nil {
+				b.onDiscards(v)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// Len returns the number of buffered items.
+func (b *Batcher[T]) Len() int {
+	return b.rb.Len()
+}
+
+// Discards returns the number of discarded items.
+func (b *Batcher[T]) Discards() uint64 {
+	return b.rb.Discards() + atomic.LoadUint64(&b.discards)
+}
+
+func (b *Batcher[T]) loop() {
+	defer close(b.stopped)
+	tick := b.after(b.interval)
+	for {
+		select {
+		case <-b.closing:
+			return
+		case <-b.kick:
+		case <-tick:
+			tick = b.after(b.interval)
+		}
+		_ = b.flushAll(b.ctx)
+	}
+}
+
+// flushAll flushes the buffered items in batches, stops at the first error.
+func (b *Batcher[T]) flushAll(ctx context.Context) error {
+	for {
+		items := b.rb.peekN(b.size)
+		if len(items) == 0 {
+			return nil
+		}
+		if err := b.deliver(ctx, items); err != nil {
+			return err
+		}
+
+		// only the flushing goroutine removes items, they are still at the front
+		b.rb.skip(len(items))
+	}
+}
+
+func (b *Batcher[T]) deliver(ctx context.Context, items []T) error {
+	for attempt := 1; ; attempt++ {
+		err := b.flush(ctx, items)
+		if err == nil || b.backoff == nil {
+			return err
+		}
+
+		d, ok := b.backoff(attempt, err)
+		if !ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.after(d):
+		}
+	}
+}
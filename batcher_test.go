@@ -0,0 +1,194 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+	calls  int
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	ch := make(chan time.Time, 1)
+	c.timers = append(c.timers, fakeTimer{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	timers := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			timers = append(timers, t)
+			continue
+		}
+		t.ch <- c.now
+	}
+	c.timers = timers
+}
+
+// WaitCalls waits until After has been called n times.
+func (c *fakeClock) WaitCalls(n int) {
+	for {
+		c.mu.Lock()
+		calls := c.calls
+		c.mu.Unlock()
+		if calls >= n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestBatcher(t *testing.T) {
+	clock := newFakeClock()
+	batches := make(chan []int, 10)
+	fail := 0
+	b := NewBatcher[int](3, 200*time.Millisecond, 0, func(ctx context.Context, items []int) error {
+		if fail > 0 {
+			fail--
+			return errors.New("flush failed")
+		}
+		batches <- items
+		return nil
+	})
+	b.SetClock(clock.After)
+	var attempts []int
+	b.SetBackoff(func(attempt int, err error) (time.Duration, bool) {
+		attempts = append(attempts, attempt)
+		return 10 * time.Millisecond, attempt < 2
+	})
+
+	// flush by size
+	b.Add(1)
+	b.Add(2)
+	b.Add(3)
+	assert.Equal(t, []int{1, 2, 3}, <-batches)
+
+	// flush by interval, the size flush did not start another timer
+	b.Add(4)
+	clock.WaitCalls(1)
+	clock.Advance(200 * time.Millisecond)
+	assert.Equal(t, []int{4}, <-batches)
+
+	// retry with backoff
+	fail = 1
+	b.Add(5)
+	clock.WaitCalls(2)
+	clock.Advance(200 * time.Millisecond)
+	clock.WaitCalls(4)
+	clock.Advance(10 * time.Millisecond)
+	assert.Equal(t, []int{5}, <-batches)
+	assert.Equal(t, []int{1}, attempts)
+	assert.Equal(t, 0, len(batches))
+
+	// final flush on close
+	b.Add(6)
+	assert.Nil(t, b.Close(context.Background()))
+	assert.Equal(t, []int{6}, <-batches)
+	assert.Equal(t, 0, b.Len())
+
+	// closed
+	b.Add(7)
+	assert.Equal(t, uint64(1), b.Discards())
+	assert.Nil(t, b.Close(context.Background()))
+}
+
+func TestBatcher_Timer(t *testing.T) {
+	clock := newFakeClock()
+	var mu sync.Mutex
+	flushed := 0
+	b := NewBatcher[int](1, time.Second, 0, func(ctx context.Context, items []int) error {
+		mu.Lock()
+		flushed += len(items)
+		mu.Unlock()
+		return nil
+	})
+	b.SetClock(clock.After)
+
+	// size flushes keep the single pending interval timer
+	for i := 0; i < 100; i++ {
+		b.Add(i)
+	}
+	assert.Nil(t, b.Close(context.Background()))
+	assert.Equal(t, 100, flushed)
+	clock.mu.Lock()
+	assert.Equal(t, 1, clock.calls)
+	clock.mu.Unlock()
+}
+
+func TestBatcher_Discards(t *testing.T) {
+	clock := newFakeClock()
+	errFlush := errors.New("flush failed")
+	b := NewBatcher[int](2, time.Second, 4, func(ctx context.Context, items []int) error {
+		return errFlush
+	})
+	b.SetClock(clock.After)
+	var mu sync.Mutex
+	var discards []int
+	b.SetOnDiscards(func(v int) {
+		// the callback runs without the lock held
+		_ = b.Len()
+		_ = b.Discards()
+		mu.Lock()
+		discards = append(discards, v)
+		mu.Unlock()
+	})
+
+	// undelivered items stay in the buffer until maxSize
+	for i := 0; i < 6; i++ {
+		b.Add(i)
+	}
+	assert.Equal(t, 4, b.Len())
+	mu.Lock()
+	assert.Equal(t, []int{4, 5}, discards)
+	mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.Equal(t, errFlush, b.Close(ctx))
+	assert.Equal(t, 0, b.Len())
+	assert.Equal(t, []int{4, 5, 0, 1, 2, 3}, discards)
+	assert.Equal(t, uint64(6), b.Discards())
+}
+
+func TestBatcher_CloseWithoutAdd(t *testing.T) {
+	b := NewBatcher[int](2, time.Second, 0, func(ctx context.Context, items []int) error {
+		t.Fatal("unexpected flush")
+		return nil
+	})
+	assert.Nil(t, b.Close(context.Background()))
+}
@@ -0,0 +1,169 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrLapped = errors.New("ringbuffer cursor is lapped")
+
+// LappedError is returned by BroadcastCursorOf.Read when the writer has overwritten unread items.
+// errors.Is(err, ErrLapped) reports true for it.
+type LappedError struct {
+	// Missed is the number of items the cursor skipped.
+	Missed uint64
+}
+
+func (e *LappedError) Error() string {
+	return fmt.Sprintf("%s, missed %d items", ErrLapped, e.Missed)
+}
+
+func (e *LappedError) Is(err error) bool {
+	return err == ErrLapped
+}
+
+// BroadcastRingOf is a fixed size ring buffer with one writer and many cursors,
+// each cursor sees every item written after it subscribed.
+// By default a slow cursor is lapped by the writer and gets a *LappedError,
+// in blocking mode the writer waits for the slowest cursor instead.
+// It is thread-safe(goroutine-safe).
+type BroadcastRingOf[T any] struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	buf      []T
+	size     uint64
+	seq      uint64 // sequence of the next item to write
+	blocking bool
+	cursors  map[*BroadcastCursorOf[T]]struct{}
+}
+
+// BroadcastCursorOf is an independent read position in a BroadcastRingOf.
+type BroadcastCursorOf[T any] struct {
+	ring *BroadcastRingOf[T]
+	next uint64 // sequence of the next item to read
+}
+
+// NewBroadcastRingOf creates a BroadcastRingOf that keeps the last size items.
+func NewBroadcastRingOf[T any](size int) *BroadcastRingOf[T] {
+	if size < minBufferSize {
+		size = minBufferSize
+	}
+
+	b := &BroadcastRingOf[T]{
+		buf:     make([]T, size),
+		size:    uint64(size),
+		cursors: make(map[*BroadcastCursorOf[T]]struct{}),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// SetBlocking sets whether Write blocks until the slowest cursor has room,
+// instead of lapping it.
+func (b *BroadcastRingOf[T]) SetBlocking(blocking bool) {
+	b.mu.Lock()
+	b.blocking = blocking
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// Subscribe returns a new cursor, it reads the items written from now on.
+func (b *BroadcastRingOf[T]) Subscribe() *BroadcastCursorOf[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := &BroadcastCursorOf[T]{
+		ring: b,
+		next: b.seq,
+	}
+	b.cursors[c] = struct{}{}
+	return c
+}
+
+func (b *BroadcastRingOf[T]) Write(v T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.blocking && b.full() {
+		b.cond.Wait()
+	}
+
+	b.buf[b.seq%b.size] = v
+	b.seq++
+}
+
+// full reports whether the next write would overwrite an item unread by some cursor.
+func (b *BroadcastRingOf[T]) full() bool {
+	for c := range b.cursors {
+		if b.seq-c.next >= b.size {
+			return true
+		}
+	}
+	return false
+}
+
+// Capacity returns the number of items kept for the cursors.
+func (b *BroadcastRingOf[T]) Capacity() int {
+	return int(b.size)
+}
+
+// Cursors returns the number of subscribed cursors.
+func (b *BroadcastRingOf[T]) Cursors() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.cursors)
+}
+
+// Read reads the next item of the cursor.
+// It returns ErrIsEmpty if there are no new items, or a *LappedError if the cursor was lapped,
+// the cursor then continues from the oldest item still kept.
+func (c *BroadcastCursorOf[T]) Read() (T, error) {
+	b := c.ring
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var t T
+	if c.next == b.seq {
+		return t, ErrIsEmpty
+	}
+
+	if b.seq-c.next > b.size {
+		oldest := b.seq - b.size
+		missed := oldest - c.next
+		c.next = oldest
+		return t, &LappedError{Missed: missed}
+	}
+
+	v := b.buf[c.next%b.size]
+	c.next++
+	if b.blocking {
+		b.cond.Broadcast()
+	}
+	return v, nil
+}
+
+// Len returns the number of items the cursor can read.
+func (c *BroadcastCursorOf[T]) Len() int {
+	b := c.ring
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := b.seq - c.next
+	if n > b.size {
+		return int(b.size)
+	}
+	return int(n)
+}
+
+// Close unsubscribes the cursor, the writer no longer waits for it.
+func (c *BroadcastCursorOf[T]) Close() {
+	b := c.ring
+	b.mu.Lock()
+	delete(b.cursors, c)
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
@@ -0,0 +1,128 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestBroadcastRingOf(t *testing.T) {
+	b := NewBroadcastRingOf[int](4)
+	b.Write(-1)
+
+	c1 := b.Subscribe()
+	c2 := b.Subscribe()
+	assert.Equal(t, 2, b.Cursors())
+	_, err := c1.Read()
+	assert.Equal(t, ErrIsEmpty, err)
+
+	for i := 0; i < 3; i++ {
+		b.Write(i)
+	}
+	assert.Equal(t, 3, c1.Len())
+
+	for i := 0; i < 3; i++ {
+		v, err := c1.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, i, v)
+	}
+	v, err := c2.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, v)
+
+	// both cursors are lapped
+	for i := 3; i < 8; i++ {
+		b.Write(i)
+	}
+	assert.Equal(t, 4, c2.Len())
+
+	_, err = c2.Read()
+	assert.True(t, errors.Is(err, ErrLapped))
+	var lapped *LappedError
+	assert.True(t, errors.As(err, &lapped))
+	assert.Equal(t, uint64(3), lapped.Missed)
+	assert.Equal(t, "ringbuffer cursor is lapped, missed 3 items", err.Error())
+
+	for i := 4; i < 8; i++ {
+		v, err := c2.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, i, v)
+	}
+	_, err = c2.Read()
+	assert.Equal(t, ErrIsEmpty, err)
+
+	_, err = c1.Read()
+	assert.True(t, errors.As(err, &lapped))
+	assert.Equal(t, uint64(1), lapped.Missed)
+	v, err = c1.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, v)
+
+	c1.Close()
+	c2.Close()
+	assert.Equal(t, 0, b.Cursors())
+	assert.Equal(t, 4, b.Capacity())
+}
+
+func TestBroadcastRingOf_Blocking(t *testing.T) {
+	b := NewBroadcastRingOf[int](2)
+	b.SetBlocking(true)
+	cursors := []*BroadcastCursorOf[int]{b.Subscribe(), b.Subscribe(), b.Subscribe()}
+
+	const n = 1000
+	var wg sync.WaitGroup
+	results := make([][]int, len(cursors))
+	for i, c := range cursors {
+		wg.Add(1)
+		go func(i int, c *BroadcastCursorOf[int]) {
+			defer wg.Done()
+			for len(results[i]) < n {
+				v, err := c.Read()
+				if err == ErrIsEmpty {
+					runtime.Gosched()
+					continue
+				}
+				assert.Nil(t, err)
+				results[i] = append(results[i], v)
+			}
+		}(i, c)
+	}
+
+	for i := 0; i < n; i++ {
+		b.Write(i)
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		for i, v := range res {
+			assert.Equal(t, i, v)
+		}
+	}
+
+	// a closed cursor no longer blocks the writer
+	c := b.Subscribe()
+	for _, c := range cursors {
+		c.Close()
+	}
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 3; i++ {
+			b.Write(i)
+		}
+		close(done)
+	}()
+	v, err := c.Read()
+	for err == ErrIsEmpty {
+		runtime.Gosched()
+		v, err = c.Read()
+	}
+	assert.Equal(t, 0, v)
+	c.Close()
+	<-done
+}
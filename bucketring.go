@@ -0,0 +1,118 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"time"
+)
+
+// BucketRing is a ring of per-interval counters, e.g. 60 one-second buckets for
+// "requests per second over the last minute".
+// Buckets rotate lazily on access, stale buckets are cleared when time jumps.
+// It is not thread-safe(goroutine-safe).
+type BucketRing struct {
+	rb       *RingBufferOf[int64] // from the oldest bucket to the current one
+	n        int
+	interval time.Duration
+	cur      time.Time // start time of the current bucket
+	now      func() time.Time
+}
+
+// NewBucketRing creates a BucketRing of n buckets, each covering interval.
+func NewBucketRing(n int, interval time.Duration) *BucketRing {
+	if n < minBufferSize {
+		n = minBufferSize
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	r := &BucketRing{
+		rb:       NewFixedOf[int64](n),
+		n:        n,
+		interval: interval,
+		now:      time.Now,
+	}
+	r.clear()
+	return r
+}
+
+// SetClock replaces the clock used to rotate buckets, useful for tests.
+func (r *BucketRing) SetClock(now func() time.Time) {
+	if now != nil {
+		r.now = now
+	}
+}
+
+// Add adds delta to the current bucket.
+func (r *BucketRing) Add(delta int64) {
+	r.rotate()
+	v, _ := r.rb.RRead()
+	r.rb.Write(v + delta)
+}
+
+// Sum returns the total of the buckets covering the last window, including the current bucket.
+func (r *BucketRing) Sum(window time.Duration) int64 {
+	r.rotate()
+	k := int((window + r.interval - 1) / r.interval)
+	if k > r.n {
+		k = r.n
+	}
+
+	var sum int64
+	for i := r.n - k; i < r.n; i++ {
+		sum += r.rb.at(i)
+	}
+	return sum
+}
+
+// Rate returns the average count per second over all buckets.
+func (r *BucketRing) Rate() float64 {
+	window := time.Duration(r.n) * r.interval
+	return float64(r.Sum(window)) / window.Seconds()
+}
+
+// Buckets returns the counters from the oldest bucket to the current one.
+func (r *BucketRing) Buckets() []int64 {
+	r.rotate()
+	return r.rb.PeekAll()
+}
+
+// Interval returns the time covered by each bucket.
+func (r *BucketRing) Interval() time.Duration {
+	return r.interval
+}
+
+func (r *BucketRing) Reset() {
+	r.cur = time.Time{}
+	r.clear()
+}
+
+func (r *BucketRing) clear() {
+	for i := 0; i < r.n; i++ {
+		r.rb.Overwrite(0)
+	}
+}
+
+func (r *BucketRing) rotate() {
+	start := r.now().Truncate(r.interval)
+	if r.cur.IsZero() {
+		r.cur = start
+		return
+	}
+
+	elapsed := start.Sub(r.cur) / r.interval
+	if elapsed <= 0 {
+		// clock going backwards stays in the current bucket
+		return
+	}
+
+	if elapsed > time.Duration(r.n) {
+		elapsed = time.Duration(r.n)
+	}
+	for i := 0; i < int(elapsed); i++ {
+		r.rb.Overwrite(0)
+	}
+	r.cur = start
+}
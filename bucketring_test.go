@@ -0,0 +1,54 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestBucketRing(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewBucketRing(4, time.Second)
+	r.SetClock(func() time.Time {
+		return now
+	})
+	assert.Equal(t, []int64{0, 0, 0, 0}, r.Buckets())
+
+	r.Add(1)
+	r.Add(2)
+	now = now.Add(500 * time.Millisecond)
+	r.Add(3)
+	assert.Equal(t, []int64{0, 0, 0, 6}, r.Buckets())
+
+	now = now.Add(time.Second)
+	r.Add(4)
+	now = now.Add(time.Second)
+	r.Add(5)
+	assert.Equal(t, []int64{0, 6, 4, 5}, r.Buckets())
+	assert.Equal(t, int64(5), r.Sum(time.Second))
+	assert.Equal(t, int64(9), r.Sum(1500*time.Millisecond))
+	assert.Equal(t, int64(15), r.Sum(time.Hour))
+	assert.Equal(t, 15.0/4, r.Rate())
+
+	// the clock going backwards stays in the current bucket
+	now = now.Add(-3 * time.Second)
+	r.Add(1)
+	assert.Equal(t, []int64{0, 6, 4, 6}, r.Buckets())
+
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, []int64{4, 6, 0, 0}, r.Buckets())
+
+	// stale buckets are cleared
+	now = now.Add(time.Minute)
+	assert.Equal(t, []int64{0, 0, 0, 0}, r.Buckets())
+	r.Add(7)
+	assert.Equal(t, int64(7), r.Sum(time.Second))
+
+	r.Reset()
+	assert.Equal(t, []int64{0, 0, 0, 0}, r.Buckets())
+	assert.Equal(t, time.Second, r.Interval())
+}
@@ -0,0 +1,125 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+)
+
+// Stats is the final report of FromChan, ToChan and Pipe.
+type Stats struct {
+	// Received is the number of items taken from the source.
+	Received uint64
+	// Delivered is the number of items handed to the destination.
+	Delivered uint64
+	// Discarded is the number of received items that were not delivered,
+	// because the destination ring was at maxSize or closed.
+	Discarded uint64
+}
+
+// FromChan pumps items from ch into rb until ch is closed, rb is closed or ctx is done.
+// Items over the maxSize of rb are discarded, as with Write,
+// or overwrite the oldest items if overwrite is true, as with Overwrite.
+func FromChan[T any](ctx context.Context, ch <-chan T, rb *ConcurrentRingOf[T], overwrite ...bool) Stats {
+	ow := len(overwrite) > 0 && overwrite[0]
+	var st Stats
+	for {
+		select {
+		case <-ctx.Done():
+			return st
+		case v, ok := <-ch:
+			if !ok {
+				return st
+			}
+			st.Received++
+			ok, err := rb.write(v, ow)
+			if ok {
+				st.Delivered++
+			} else {
+				st.Discarded++
+			}
+			if err != nil {
+				return st
+			}
+		}
+	}
+}
+
+// ToChan drains rb into the returned channel until ctx is done or rb is closed and drained,
+// then closes the channel and sends the final Stats.
+// An item read but not received before ctx is done is put back into rb, it is not lost.
+func ToChan[T any](ctx context.Context, rb *ConcurrentRingOf[T]) (<-chan T, <-chan Stats) {
+	out := make(chan T)
+	stats := make(chan Stats, 1)
+	go func() {
+		var st Stats
+		defer func() {
+			close(out)
+			stats <- st
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-rb.NotEmpty():
+			}
+
+			for ctx.Err() == nil {
+				v, err := rb.Read()
+				if err == ErrClosed {
+					return
+				}
+				if err != nil {
+					break
+				}
+				select {
+				case <-ctx.Done():
+					rb.unread(v)
+					return
+				case out <- v:
+					st.Received++
+					st.Delivered++
+				}
+			}
+		}
+	}()
+	return out, stats
+}
+
+// Pipe moves items from src to dst, converted by transform,
+// until ctx is done, src is closed and drained or dst is closed.
+// Items over the maxSize of dst are discarded, as with Write,
+// or overwrite the oldest items if overwrite is true, as with Overwrite.
+func Pipe[T, U any](ctx context.Context, src *ConcurrentRingOf[T], dst *ConcurrentRingOf[U], transform func(T) U, overwrite ...bool) Stats {
+	ow := len(overwrite) > 0 && overwrite[0]
+	var st Stats
+	for {
+		select {
+		case <-ctx.Done():
+			return st
+		case <-src.NotEmpty():
+		}
+
+		for ctx.Err() == nil {
+			v, err := src.Read()
+			if err == ErrClosed {
+				return st
+			}
+			if err != nil {
+				break
+			}
+			st.Received++
+			ok, err := dst.write(transform(v), ow)
+			if ok {
+				st.Delivered++
+			} else {
+				st.Discarded++
+			}
+			if err != nil {
+				return st
+			}
+		}
+	}
+}
@@ -0,0 +1,172 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+// checkGoroutines fails the test if goroutines started after n are still running.
+func checkGoroutines(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutine leak: %d > %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFromChan(t *testing.T) {
+	n := runtime.NumGoroutine()
+	rb := NewConcurrentRingOf[int](2, 3)
+	ch := make(chan int)
+	done := make(chan Stats)
+	go func() {
+		done <- FromChan(context.Background(), ch, rb)
+	}()
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	st := <-done
+	assert.Equal(t, Stats{Received: 5, Delivered: 3, Discarded: 2}, st)
+	assert.Equal(t, 3, rb.Len())
+	checkGoroutines(t, n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		done <- FromChan(ctx, make(chan int), rb)
+	}()
+	cancel()
+	assert.Equal(t, Stats{}, <-done)
+	checkGoroutines(t, n)
+}
+
+func TestFromChan_Overwrite(t *testing.T) {
+	rb := NewConcurrentRingOf[int](2, 3)
+	ch := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		ch <- i
+	}
+	close(ch)
+
+	st := FromChan(context.Background(), ch, rb, true)
+	assert.Equal(t, Stats{Received: 5, Delivered: 5}, st)
+	for i := 2; i < 5; i++ {
+		v, _ := rb.Read()
+		assert.Equal(t, i, v)
+	}
+
+	src := NewConcurrentRingOf[int](2)
+	dst := NewConcurrentRingOf[string](2, 2)
+	for i := 0; i < 3; i++ {
+		src.Write(i)
+	}
+	src.Close()
+	st = Pipe(context.Background(), src, dst, strconv.Itoa, true)
+	assert.Equal(t, Stats{Received: 3, Delivered: 3}, st)
+	v, _ := dst.Read()
+	assert.Equal(t, "1", v)
+}
+
+func TestToChan(t *testing.T) {
+	n := runtime.NumGoroutine()
+	rb := NewConcurrentRingOf[int](2)
+	for i := 0; i < 3; i++ {
+		rb.Write(i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out, stats := ToChan(ctx, rb)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, i, <-out)
+	}
+	rb.Write(3)
+	assert.Equal(t, 3, <-out)
+
+	// the next item is read but never received, it is put back
+	rb.Write(4)
+	rb.Write(5)
+	for rb.Len() > 1 {
+		runtime.Gosched()
+	}
+	cancel()
+	assert.Equal(t, Stats{Received: 4, Delivered: 4}, <-stats)
+	_, ok := <-out
+	assert.False(t, ok)
+	assert.Equal(t, 2, rb.Len())
+	v, _ := rb.Read()
+	assert.Equal(t, 4, v)
+	v, _ = rb.Read()
+	assert.Equal(t, 5, v)
+	checkGoroutines(t, n)
+}
+
+func TestPipe(t *testing.T) {
+	n := runtime.NumGoroutine()
+	src := NewConcurrentRingOf[int](2)
+	dst := NewConcurrentRingOf[string](2, 4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan Stats)
+	go func() {
+		done <- Pipe(ctx, src, dst, strconv.Itoa)
+	}()
+
+	for i := 0; i < 6; i++ {
+		src.Write(i)
+	}
+	for dst.Discards() < 2 {
+		runtime.Gosched()
+	}
+	cancel()
+
+	assert.Equal(t, Stats{Received: 6, Delivered: 4, Discarded: 2}, <-done)
+	for i := 0; i < 4; i++ {
+		v, err := dst.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, strconv.Itoa(i), v)
+	}
+	checkGoroutines(t, n)
+}
+
+func TestChan_Close(t *testing.T) {
+	n := runtime.NumGoroutine()
+	rb := NewConcurrentRingOf[int](2)
+	rb.Write(1)
+	rb.Write(2)
+	rb.Close()
+
+	// the remaining items are delivered, then the channel is closed
+	out, stats := ToChan(context.Background(), rb)
+	assert.Equal(t, 1, <-out)
+	assert.Equal(t, 2, <-out)
+	_, ok := <-out
+	assert.False(t, ok)
+	assert.Equal(t, Stats{Received: 2, Delivered: 2}, <-stats)
+
+	ch := make(chan int, 2)
+	ch <- 1
+	ch <- 2
+	assert.Equal(t, Stats{Received: 1, Discarded: 1}, FromChan(context.Background(), ch, rb))
+	assert.Equal(t, 1, len(ch))
+
+	src := NewConcurrentRingOf[int](2)
+	src.Write(1)
+	src.Close()
+	dst := NewConcurrentRingOf[string](2)
+	assert.Equal(t, Stats{Received: 1, Delivered: 1}, Pipe(context.Background(), src, dst, strconv.Itoa))
+	v, _ := dst.Read()
+	assert.Equal(t, "1", v)
+	checkGoroutines(t, n)
+}
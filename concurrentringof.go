@@ -0,0 +1,244 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrClosed = errors.New("ringbuffer is closed")
+
+// ConcurrentRingOf is a RingBufferOf guarded by a mutex, with channels for select loops.
+// NotEmpty and NotFull are edge-coalesced: a burst of writes produces a single wakeup.
+// A wakeup is a hint, another goroutine may have consumed the data in the meantime,
+// so Read until ErrIsEmpty after receiving from NotEmpty.
+// Callbacks are called with the lock held and must not call back into the ring.
+// After Close, writes fail with ErrClosed and reads drain the remaining items.
+// It is thread-safe(goroutine-safe).
+type ConcurrentRingOf[T any] struct {
+	mu       sync.Mutex
+	rb       *RingBufferOf[T]
+	notEmpty chan struct{}
+	notFull  chan struct{}
+	done     chan struct{}
+	closed   bool
+}
+
+func NewConcurrentRingOf[T any](initialSize int, maxBufferSize ...int) *ConcurrentRingOf[T] {
+	r := &ConcurrentRingOf[T]{
+		rb:       NewOf[T](initialSize, maxBufferSize...),
+		notEmpty: make(chan struct{}, 1),
+		notFull:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	r.signal()
+	return r
+}
+
+// NotEmpty returns a channel that is ready when the ring has data to read, or is closed.
+func (r *ConcurrentRingOf[T]) NotEmpty() <-chan struct{} {
+	return r.notEmpty
+}
+
+// NotFull returns a channel that is ready when the ring is below its maxSize.
+// It is always ready for an unbounded ring.
+func (r *ConcurrentRingOf[T]) NotFull() <-chan struct{} {
+	return r.notFull
+}
+
+// Done returns a channel that is closed when the ring is closed.
+func (r *ConcurrentRingOf[T]) Done() <-chan struct{} {
+	return r.done
+}
+
+// Close closes the ring, writes fail with ErrClosed from now on,
+// the remaining items can still be read. Blocked ReadContext calls wake up.
+// Closing a closed ring does nothing.
+func (r *ConcurrentRingOf[T]) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	close(r.done)
+	r.signal()
+}
+
+// Read reads the oldest item, it does not block.
+// It returns ErrIsEmpty, or ErrClosed once the ring is closed and drained.
+func (r *ConcurrentRingOf[T]) Read() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, err := r.rb.Read()
+	if err == ErrIsEmpty && r.closed {
+		err = ErrClosed
+	}
+	r.signal()
+	return v, err
+}
+
+// ReadContext reads the oldest item, waiting until there is one, the ring is closed and drained, or ctx is done.
+func (r *ConcurrentRingOf[T]) ReadContext(ctx context.Context) (T, error) {
+	for {
+		v, err := r.Read()
+		if err != ErrIsEmpty {
+			return v, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return v, ctx.Err()
+		case <-r.notEmpty:
+		case <-r.done:
+		}
+	}
+}
+
+func (r *ConcurrentRingOf[T]) Peek() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.Peek()
+}
+
+// Write writes v, exceeding maxSize, v will be discarded.
+// It returns ErrClosed after Close.
+func (r *ConcurrentRingOf[T]) Write(v T) error {
+	_, err := r.write(v, false)
+	return err
+}
+
+// Overwrite write, when the buffer reaches the maximum value, overwrite unread data.
+// It returns ErrClosed after Close.
+func (r *ConcurrentRingOf[T]) Overwrite(v T) error {
+	_, err := r.write(v, true)
+	return err
+}
+
+// write writes v with Write or Overwrite, and reports whether it was stored rather than discarded.
+func (r *ConcurrentRingOf[T]) write(v T, overwrite bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false, ErrClosed
+	}
+	discards := r.rb.discards
+	if overwrite {
+		r.rb.Overwrite(v)
+	} else {
+		r.rb.Write(v)
+	}
+	r.signal()
+	return r.rb.discards == discards, nil
+}
+
+// unread puts v back as the oldest item, even over maxSize or after Close, so that it is not lost.
+func (r *ConcurrentRingOf[T]) unread(v T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rb.insert(0, v, r.rb.sizeOf(v))
+	r.signal()
+}
+
+// peekN returns up to n of the oldest items, without reading them.
+func (r *ConcurrentRingOf[T]) peekN(n int) []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l := r.rb.Len(); n > l {
+		n = l
+	}
+	items := make([]T, n)
+	for i := range items {
+		items[i] = r.rb.at(i)
+	}
+	return items
+}
+
+// skip reads and drops up to n of the oldest items.
+func (r *ConcurrentRingOf[T]) skip(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := 0; i < n; i++ {
+		if _, err := r.rb.Read(); err != nil {
+			break
+		}
+	}
+	r.signal()
+}
+
+func (r *ConcurrentRingOf[T]) IsEmpty() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.IsEmpty()
+}
+
+// Capacity returns the size of the underlying buffer.
+func (r *ConcurrentRingOf[T]) Capacity() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.Capacity()
+}
+
+func (r *ConcurrentRingOf[T]) MaxSize() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.MaxSize()
+}
+
+func (r *ConcurrentRingOf[T]) Discards() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.Discards()
+}
+
+func (r *ConcurrentRingOf[T]) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.Len()
+}
+
+func (r *ConcurrentRingOf[T]) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rb.Reset()
+	r.signal()
+}
+
+func (r *ConcurrentRingOf[T]) SetMaxSize(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n = r.rb.SetMaxSize(n)
+	r.signal()
+	return n
+}
+
+func (r *ConcurrentRingOf[T]) SetOnDiscards(fn func(T)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rb.SetOnDiscards(fn)
+}
+
+// signal updates the channels to the current state, it must be called with the lock held.
+func (r *ConcurrentRingOf[T]) signal() {
+	notify(r.notEmpty, !r.rb.IsEmpty() || r.closed)
+	notify(r.notFull, r.rb.maxSize <= 0 || r.rb.Len() < r.rb.maxSize)
+}
+
+// notify makes ch ready if ok, otherwise drains the stale wakeup.
+func notify(ch chan struct{}, ok bool) {
+	if ok {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	select {
+	case <-ch:
+	default:
+	}
+}
@@ -0,0 +1,161 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestConcurrentRingOf_Notify(t *testing.T) {
+	r := NewConcurrentRingOf[int](2, 3)
+	assert.Equal(t, 0, len(r.NotEmpty()))
+	assert.Equal(t, 1, len(r.NotFull()))
+
+	// a burst of writes produces a single wakeup
+	r.Write(1)
+	r.Write(2)
+	assert.Equal(t, 1, len(r.NotEmpty()))
+	assert.Equal(t, 1, len(r.NotFull()))
+
+	r.Write(3)
+	assert.Equal(t, 0, len(r.NotFull()))
+	r.Write(4)
+	assert.Equal(t, uint64(1), r.Discards())
+
+	<-r.NotEmpty()
+	v, err := r.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 1, len(r.NotEmpty()))
+	assert.Equal(t, 1, len(r.NotFull()))
+
+	// drained rings have no stale wakeups
+	_, _ = r.Read()
+	_, _ = r.Read()
+	assert.Equal(t, 0, len(r.NotEmpty()))
+
+	r.Overwrite(5)
+	assert.Equal(t, 1, len(r.NotEmpty()))
+	r.Reset()
+	assert.Equal(t, 0, len(r.NotEmpty()))
+	assert.True(t, r.IsEmpty())
+
+	// unbounded rings are never full
+	r.SetMaxSize(0)
+	for i := 0; i < 10; i++ {
+		r.Write(i)
+	}
+	assert.Equal(t, 1, len(r.NotFull()))
+	assert.Equal(t, 10, r.Len())
+	assert.Equal(t, 0, r.MaxSize())
+}
+
+func TestConcurrentRingOf_Select(t *testing.T) {
+	r := NewConcurrentRingOf[int](4, 8)
+	const n = 1000
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < n; {
+			select {
+			case <-r.NotFull():
+				r.Write(i)
+				i++
+			case <-time.After(time.Second):
+				t.Error("producer timeout")
+				return
+			}
+		}
+	}()
+
+	var got []int
+	for len(got) < n {
+		select {
+		case <-r.NotEmpty():
+			for {
+				v, err := r.Read()
+				if err == ErrIsEmpty {
+					break
+				}
+				got = append(got, v)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("consumer timeout")
+		}
+	}
+	wg.Wait()
+
+	for i, v := range got {
+		assert.Equal(t, i, v)
+	}
+	assert.Equal(t, uint64(0), r.Discards())
+}
+
+func TestConcurrentRingOf_Close(t *testing.T) {
+	r := NewConcurrentRingOf[int](2)
+	assert.Nil(t, r.Write(1))
+	assert.Nil(t, r.Overwrite(2))
+	select {
+	case <-r.Done():
+		t.Fatal("done before close")
+	default:
+	}
+
+	r.Close()
+	r.Close()
+	<-r.Done()
+
+	assert.Equal(t, ErrClosed, r.Write(3))
+	assert.Equal(t, ErrClosed, r.Overwrite(3))
+	assert.Equal(t, 2, r.Len())
+
+	// the remaining items are drained first
+	v, err := r.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, v)
+	v, err = r.ReadContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, v)
+	_, err = r.Read()
+	assert.Equal(t, ErrClosed, err)
+	_, err = r.ReadContext(context.Background())
+	assert.Equal(t, ErrClosed, err)
+	assert.Equal(t, 1, len(r.NotEmpty()))
+}
+
+func TestConcurrentRingOf_ReadContext(t *testing.T) {
+	r := NewConcurrentRingOf[int](2)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := r.ReadContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	got := make(chan int)
+	go func() {
+		v, _ := r.ReadContext(context.Background())
+		got <- v
+	}()
+	r.Write(1)
+	assert.Equal(t, 1, <-got)
+
+	// all blocked readers wake up
+	errs := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := r.ReadContext(context.Background())
+			errs <- err
+		}()
+	}
+	r.Close()
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, ErrClosed, <-errs)
+	}
+}
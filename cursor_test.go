@@ -0,0 +1,74 @@
+package ringbuffer
+
+import (
+	"testing"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestCursor(t *testing.T) {
+	rb := New(2, 4)
+	c := rb.Cursor()
+	_, ok := c.Next()
+	assert.False(t, ok)
+	assert.Nil(t, c.Err())
+
+	// survives writes and grow
+	rb.Write(0)
+	rb.Write(1)
+	v, ok := c.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 0, v)
+	rb.Write(2)
+	rb.Write(3)
+	for i := 1; i < 4; i++ {
+		v, ok = c.Next()
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+	_, ok = c.Next()
+	assert.False(t, ok)
+	assert.Nil(t, c.Err())
+
+	assert.Nil(t, c.Seek(2))
+	v, _ = c.Next()
+	assert.Equal(t, 2, v)
+	assert.Equal(t, ErrOutOfRange, c.Seek(5))
+	assert.Equal(t, ErrOutOfRange, c.Seek(-1))
+
+	// overwrite evicts an item before the cursor position
+	rb.Overwrite(4)
+	v, ok = c.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	// overwrite evicts the cursor position
+	assert.Nil(t, c.Seek(0))
+	rb.Overwrite(5)
+	_, ok = c.Next()
+	assert.False(t, ok)
+	assert.Equal(t, ErrInvalidated, c.Err())
+	_, ok = c.Next()
+	assert.False(t, ok)
+
+	assert.Nil(t, c.Seek(0))
+	assert.Nil(t, c.Err())
+	v, _ = c.Next()
+	assert.Equal(t, 2, v)
+
+	_, _ = rb.Read()
+	v, ok = c.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	_, _ = rb.RRead()
+	_, ok = c.Next()
+	assert.False(t, ok)
+	assert.Equal(t, ErrInvalidated, c.Err())
+
+	c = rb.Cursor()
+	rb.Truncate(0)
+	_, ok = c.Next()
+	assert.False(t, ok)
+	assert.Equal(t, ErrInvalidated, c.Err())
+}
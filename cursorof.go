@@ -0,0 +1,63 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+// CursorOf iterates over the unread items of a RingBufferOf, from the oldest to the newest.
+// It tracks its position by the number of items read before it, so it survives Write, Overwrite and grow.
+// Once the item at its position is read, overwritten or truncated, or the buffer is
+// modified by RRead, the cursor is invalidated and Err returns ErrInvalidated.
+type CursorOf[T any] struct {
+	rb   *RingBufferOf[T]
+	pos  uint64 // position of the next item, counted from the first item ever written
+	mods uint64
+	err  error
+}
+
+// Cursor returns a cursor positioned at the oldest unread item.
+func (r *RingBufferOf[T]) Cursor() *CursorOf[T] {
+	return &CursorOf[T]{
+		rb:   r,
+		pos:  r.head,
+		mods: r.mods,
+	}
+}
+
+// Next returns the next item, false if there are no more items or the cursor is invalidated.
+// After writing more items, Next can be called again.
+func (c *CursorOf[T]) Next() (T, bool) {
+	var t T
+	if c.err != nil {
+		return t, false
+	}
+
+	if c.mods != c.rb.mods || c.pos < c.rb.head {
+		c.err = ErrInvalidated
+		return t, false
+	}
+
+	if c.pos >= c.rb.head+uint64(c.rb.Len()) {
+		return t, false
+	}
+
+	v := c.rb.at(int(c.pos - c.rb.head))
+	c.pos++
+	return v, true
+}
+
+// Seek moves the cursor to the i-th unread item, 0 is the oldest, and clears the error.
+func (c *CursorOf[T]) Seek(i int) error {
+	if i < 0 || i > c.rb.Len() {
+		return ErrOutOfRange
+	}
+
+	c.pos = c.rb.head + uint64(i)
+	c.mods = c.rb.mods
+	c.err = nil
+	return nil
+}
+
+// Err returns ErrInvalidated if the cursor is invalidated.
+func (c *CursorOf[T]) Err() error {
+	return c.err
+}
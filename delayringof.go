@@ -0,0 +1,253 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DelayRingOf is a delay queue, an item becomes readable only after its delay has passed.
+// Pending items are kept in a timing wheel whose slots are RingBufferOf buckets,
+// so WriteAfter is O(1). Items become readable in the order of their slots,
+// the order within a tick is not guaranteed.
+// It is not thread-safe(goroutine-safe), see ConcurrentDelayRingOf.
+type DelayRingOf[T any] struct {
+	slots   []*RingBufferOf[delayItem[T]]
+	tick    time.Duration
+	cur     time.Time // start time of the current slot
+	pos     int       // index of the current slot
+	pending int
+	ready   *RingBufferOf[T]
+	now     func() time.Time
+}
+
+type delayItem[T any] struct {
+	due time.Time
+	v   T
+}
+
+// NewDelayRingOf creates a DelayRingOf with a timing wheel of n slots, each covering tick.
+// Delays longer than n*tick are supported, such items are checked again on each turn of the wheel.
+func NewDelayRingOf[T any](n int, tick time.Duration) *DelayRingOf[T] {
+	if n < 1 {
+		n = 1
+	}
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+
+	slots := make([]*RingBufferOf[delayItem[T]], n)
+	for i := range slots {
+		slots[i] = NewUnboundedOf[delayItem[T]](minBufferSize)
+	}
+	return &DelayRingOf[T]{
+		slots: slots,
+		tick:  tick,
+		ready: NewUnboundedOf[T](minBufferSize),
+		now:   time.Now,
+	}
+}
+
+// SetClock replaces the clock, useful for tests.
+func (r *DelayRingOf[T]) SetClock(now func() time.Time) {
+	if now != nil {
+		r.now = now
+	}
+}
+
+// WriteAfter writes v, readable after d.
+func (r *DelayRingOf[T]) WriteAfter(v T, d time.Duration) {
+	now := r.now()
+	r.advance(now)
+	if d <= 0 {
+		r.ready.Write(v)
+		return
+	}
+
+	// the clock can step back, never schedule before the current slot
+	if now.Before(r.cur) {
+		now = r.cur
+	}
+	due := now.Add(d)
+	off := int(due.Sub(r.cur) / r.tick % time.Duration(len(r.slots)))
+	r.slots[(r.pos+off)%len(r.slots)].Write(delayItem[T]{due: due, v: v})
+	r.pending++
+}
+
+// Read reads an item whose delay has passed.
+func (r *DelayRingOf[T]) Read() (T, error) {
+	r.advance(r.now())
+	return r.ready.Read()
+}
+
+// ReadReady reads all items whose delay has passed at now.
+func (r *DelayRingOf[T]) ReadReady(now time.Time) (buf []T) {
+	r.advance(now)
+	for {
+		v, err := r.ready.Read()
+		if err != nil {
+			return
+		}
+		buf = append(buf, v)
+	}
+}
+
+// Len returns the number of items, pending or ready.
+func (r *DelayRingOf[T]) Len() int {
+	return r.pending + r.ready.Len()
+}
+
+// Pending returns the number of items whose delay has not passed yet, as of the last access.
+func (r *DelayRingOf[T]) Pending() int {
+	return r.pending
+}
+
+func (r *DelayRingOf[T]) Reset() {
+	for _, slot := range r.slots {
+		slot.Reset()
+	}
+	r.ready.Reset()
+	r.pending = 0
+	r.cur = time.Time{}
+	r.pos = 0
+}
+
+// advance turns the wheel to now, moving due items to the ready buffer.
+func (r *DelayRingOf[T]) advance(now time.Time) {
+	start := now.Truncate(r.tick)
+	if r.cur.IsZero() {
+		r.cur = start
+	}
+
+	steps := int64(0)
+	if start.After(r.cur) {
+		steps = int64(start.Sub(r.cur) / r.tick)
+	}
+
+	// every slot needs to be visited at most once
+	n := int64(len(r.slots))
+	visit := steps
+	if visit >= n {
+		visit = n - 1
+	}
+	for i := int64(0); i <= visit; i++ {
+		r.expire(r.slots[(int64(r.pos)+i)%n], now)
+	}
+
+	r.pos = int((int64(r.pos) + steps) % n)
+	r.cur = r.cur.Add(time.Duration(steps) * r.tick)
+}
+
+// expire moves the items of slot that are due at now to the ready buffer.
+func (r *DelayRingOf[T]) expire(slot *RingBufferOf[delayItem[T]], now time.Time) {
+	if r.pending == 0 {
+		return
+	}
+
+	for k := slot.Len(); k > 0; k-- {
+		it, _ := slot.Read()
+		if it.due.After(now) {
+			slot.Write(it)
+			continue
+		}
+		r.ready.Write(it.v)
+		r.pending--
+	}
+}
+
+// nextWait returns how long until the next slot, or false if nothing is pending.
+func (r *DelayRingOf[T]) nextWait(now time.Time) (time.Duration, bool) {
+	if r.pending == 0 {
+		return 0, false
+	}
+	return r.cur.Add(r.tick).Sub(now), true
+}
+
+// ConcurrentDelayRingOf is a DelayRingOf guarded by a mutex, with a blocking ReadContext.
+// It is thread-safe(goroutine-safe).
+type ConcurrentDelayRingOf[T any] struct {
+	mu      sync.Mutex
+	rb      *DelayRingOf[T]
+	after   func(d time.Duration) <-chan time.Time
+	written chan struct{} // closed and replaced on each write
+}
+
+// NewConcurrentDelayRingOf creates a ConcurrentDelayRingOf, see NewDelayRingOf.
+func NewConcurrentDelayRingOf[T any](n int, tick time.Duration) *ConcurrentDelayRingOf[T] {
+	return &ConcurrentDelayRingOf[T]{
+		rb:      NewDelayRingOf[T](n, tick),
+		after:   time.After,
+		written: make(chan struct{}),
+	}
+}
+
+// SetClock replaces time.Now and time.After, useful for tests.
+func (r *ConcurrentDelayRingOf[T]) SetClock(now func() time.Time, after func(d time.Duration) <-chan time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rb.SetClock(now)
+	if after != nil {
+		r.after = after
+	}
+}
+
+// WriteAfter writes v, readable after d.
+func (r *ConcurrentDelayRingOf[T]) WriteAfter(v T, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rb.WriteAfter(v, d)
+	close(r.written)
+	r.written = make(chan struct{})
+}
+
+// Read reads an item whose delay has passed, it does not block.
+func (r *ConcurrentDelayRingOf[T]) Read() (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.Read()
+}
+
+// ReadReady reads all items whose delay has passed at now.
+func (r *ConcurrentDelayRingOf[T]) ReadReady(now time.Time) []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.ReadReady(now)
+}
+
+// ReadContext reads an item, waiting until one is ready or ctx is done.
+func (r *ConcurrentDelayRingOf[T]) ReadContext(ctx context.Context) (T, error) {
+	for {
+		r.mu.Lock()
+		now := r.rb.now()
+		r.rb.advance(now)
+		v, err := r.rb.ready.Read()
+		wait, ok := r.rb.nextWait(now)
+		written, after := r.written, r.after
+		r.mu.Unlock()
+
+		if err == nil {
+			return v, nil
+		}
+
+		var timer <-chan time.Time
+		if ok {
+			timer = after(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return v, ctx.Err()
+		case <-written:
+		case <-timer:
+		}
+	}
+}
+
+// Len returns the number of items, pending or ready.
+func (r *ConcurrentDelayRingOf[T]) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rb.Len()
+}
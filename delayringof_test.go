@@ -0,0 +1,110 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestDelayRingOf(t *testing.T) {
+	clock := newFakeClock()
+	r := NewDelayRingOf[string](4, 10*time.Millisecond)
+	r.SetClock(clock.Now)
+	start := clock.Now()
+
+	r.WriteAfter("now", 0)
+	r.WriteAfter("15ms", 15*time.Millisecond)
+	r.WriteAfter("5ms", 5*time.Millisecond)
+	r.WriteAfter("100ms", 100*time.Millisecond)
+	r.WriteAfter("35ms", 35*time.Millisecond)
+	assert.Equal(t, 5, r.Len())
+	assert.Equal(t, 4, r.Pending())
+
+	v, err := r.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "now", v)
+	_, err = r.Read()
+	assert.Equal(t, ErrIsEmpty, err)
+
+	assert.Nil(t, r.ReadReady(start.Add(4*time.Millisecond)))
+	assert.Equal(t, []string{"5ms"}, r.ReadReady(start.Add(5*time.Millisecond)))
+	assert.Equal(t, []string{"15ms", "35ms"}, r.ReadReady(start.Add(50*time.Millisecond)))
+	assert.Equal(t, 1, r.Pending())
+
+	// longer than a turn of the wheel
+	assert.Nil(t, r.ReadReady(start.Add(99*time.Millisecond)))
+	assert.Equal(t, []string{"100ms"}, r.ReadReady(start.Add(time.Hour)))
+	assert.Equal(t, 0, r.Len())
+
+	clock.Advance(time.Hour)
+	r.WriteAfter("a", time.Second)
+	r.WriteAfter("b", 2*time.Second)
+	r.Reset()
+	assert.Equal(t, 0, r.Len())
+	assert.Nil(t, r.ReadReady(clock.Now().Add(time.Hour)))
+}
+
+func TestDelayRingOf_ClockBack(t *testing.T) {
+	clock := newFakeClock()
+	r := NewDelayRingOf[int](8, time.Second)
+	r.SetClock(clock.Now)
+
+	clock.Advance(10 * time.Second)
+	r.WriteAfter(1, time.Second)
+	clock.Advance(-5 * time.Second)
+	r.WriteAfter(2, time.Millisecond)
+	assert.Equal(t, 2, r.Pending())
+
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, []int{2}, r.ReadReady(clock.Now().Add(time.Millisecond)))
+	assert.Equal(t, []int{1}, r.ReadReady(clock.Now().Add(time.Second)))
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestConcurrentDelayRingOf(t *testing.T) {
+	clock := newFakeClock()
+	r := NewConcurrentDelayRingOf[int](8, 10*time.Millisecond)
+	r.SetClock(clock.Now, clock.After)
+
+	r.WriteAfter(1, 30*time.Millisecond)
+	_, err := r.Read()
+	assert.Equal(t, ErrIsEmpty, err)
+	assert.Equal(t, 1, r.Len())
+
+	done := make(chan int)
+	go func() {
+		v, err := r.ReadContext(context.Background())
+		assert.Nil(t, err)
+		done <- v
+	}()
+	// waits for the next slot
+	clock.WaitCalls(1)
+	clock.Advance(10 * time.Millisecond)
+	clock.WaitCalls(2)
+	clock.Advance(10 * time.Millisecond)
+	clock.WaitCalls(3)
+	clock.Advance(10 * time.Millisecond)
+	assert.Equal(t, 1, <-done)
+
+	// woken up by a write
+	go func() {
+		v, err := r.ReadContext(context.Background())
+		assert.Nil(t, err)
+		done <- v
+	}()
+	r.WriteAfter(2, 0)
+	assert.Equal(t, 2, <-done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = r.ReadContext(ctx)
+	assert.Equal(t, context.Canceled, err)
+
+	r.WriteAfter(3, time.Millisecond)
+	assert.Equal(t, []int{3}, r.ReadReady(clock.Now().Add(time.Millisecond)))
+}
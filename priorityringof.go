@@ -0,0 +1,148 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+// PriorityRingOf holds several RingBufferOf levels, level 0 has the highest priority.
+// By default Read uses strict priority, it reads from a level only when all higher levels
+// are empty. With SetWeights, Read uses weighted round-robin instead.
+// Each level has its own maxSize and discard callback, see Level.
+// It is not thread-safe(goroutine-safe).
+type PriorityRingOf[T any] struct {
+	levels  []*RingBufferOf[T]
+	weights []int // nil for strict priority
+	cur     int   // level of the current round-robin turn
+	used    int   // reads of the current turn
+}
+
+// NewPriorityRingOf creates a PriorityRingOf with n levels,
+// initialSize and maxBufferSize have the same meaning as in NewOf, for each level.
+func NewPriorityRingOf[T any](n, initialSize int, maxBufferSize ...int) *PriorityRingOf[T] {
+	if n < 1 {
+		n = 1
+	}
+
+	levels := make([]*RingBufferOf[T], n)
+	for i := range levels {
+		levels[i] = NewOf[T](initialSize, maxBufferSize...)
+	}
+	return &PriorityRingOf[T]{
+		levels: levels,
+	}
+}
+
+// SetWeights switches Read to weighted round-robin, level i gets up to weights[i]
+// reads per turn. Missing or non-positive weights count as 1.
+// Without weights, Read switches back to strict priority.
+func (r *PriorityRingOf[T]) SetWeights(weights ...int) {
+	r.cur, r.used = 0, 0
+	if len(weights) == 0 {
+		r.weights = nil
+		return
+	}
+
+	r.weights = make([]int, len(r.levels))
+	for i := range r.weights {
+		r.weights[i] = 1
+		if i < len(weights) && weights[i] > 0 {
+			r.weights[i] = weights[i]
+		}
+	}
+}
+
+// Level returns the i-th level, to set its maxSize, discard callback, etc.
+func (r *PriorityRingOf[T]) Level(i int) *RingBufferOf[T] {
+	return r.levels[i]
+}
+
+// Levels returns the number of levels.
+func (r *PriorityRingOf[T]) Levels() int {
+	return len(r.levels)
+}
+
+// Write writes v to the given level.
+func (r *PriorityRingOf[T]) Write(level int, v T) {
+	r.levels[level].Write(v)
+}
+
+// Overwrite writes v to the given level, overwriting its unread data at maxSize.
+func (r *PriorityRingOf[T]) Overwrite(level int, v T) {
+	r.levels[level].Overwrite(v)
+}
+
+// Read reads an item and returns the level it was read from.
+func (r *PriorityRingOf[T]) Read() (T, int, error) {
+	if r.weights == nil {
+		for i, rb := range r.levels {
+			if v, err := rb.Read(); err == nil {
+				return v, i, nil
+			}
+		}
+
+		var t T
+		return t, 0, ErrIsEmpty
+	}
+
+	// each level is visited at most once, plus the current level again with new credits
+	for i := 0; i <= len(r.levels); i++ {
+		if r.used < r.weights[r.cur] {
+			if v, err := r.levels[r.cur].Read(); err == nil {
+				r.used++
+				return v, r.cur, nil
+			}
+		}
+
+		r.cur++
+		if r.cur == len(r.levels) {
+			r.cur = 0
+		}
+		r.used = 0
+	}
+
+	var t T
+	return t, 0, ErrIsEmpty
+}
+
+func (r *PriorityRingOf[T]) IsEmpty() bool {
+	for _, rb := range r.levels {
+		if !rb.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the total number of items of all levels.
+func (r *PriorityRingOf[T]) Len() int {
+	n := 0
+	for _, rb := range r.levels {
+		n += rb.Len()
+	}
+	return n
+}
+
+// LevelLen returns the number of items of the i-th level.
+func (r *PriorityRingOf[T]) LevelLen(i int) int {
+	return r.levels[i].Len()
+}
+
+// Discards returns the total number of discarded items of all levels.
+func (r *PriorityRingOf[T]) Discards() uint64 {
+	var n uint64
+	for _, rb := range r.levels {
+		n += rb.Discards()
+	}
+	return n
+}
+
+// LevelDiscards returns the number of discarded items of the i-th level.
+func (r *PriorityRingOf[T]) LevelDiscards(i int) uint64 {
+	return r.levels[i].Discards()
+}
+
+func (r *PriorityRingOf[T]) Reset() {
+	for _, rb := range r.levels {
+		rb.Reset()
+	}
+	r.cur, r.used = 0, 0
+}
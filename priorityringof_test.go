@@ -0,0 +1,91 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"testing"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestPriorityRingOf(t *testing.T) {
+	r := NewPriorityRingOf[string](3, 2)
+	assert.Equal(t, 3, r.Levels())
+	assert.True(t, r.IsEmpty())
+	_, _, err := r.Read()
+	assert.Equal(t, ErrIsEmpty, err)
+
+	var discards []string
+	r.Level(2).SetMaxSize(2)
+	r.Level(2).SetOnDiscards(func(v string) {
+		discards = append(discards, v)
+	})
+
+	r.Write(2, "bulk1")
+	r.Write(2, "bulk2")
+	r.Write(2, "bulk3")
+	r.Write(1, "normal")
+	r.Write(0, "control")
+	assert.Equal(t, 4, r.Len())
+	assert.Equal(t, 2, r.LevelLen(2))
+	assert.Equal(t, []string{"bulk3"}, discards)
+	assert.Equal(t, uint64(1), r.Discards())
+	assert.Equal(t, uint64(1), r.LevelDiscards(2))
+	assert.Equal(t, uint64(0), r.LevelDiscards(0))
+
+	// strict priority
+	levels := map[string]int{"control": 0, "normal": 1, "bulk1": 2, "bulk2": 2}
+	var got []string
+	for {
+		v, level, err := r.Read()
+		if err != nil {
+			break
+		}
+		got = append(got, v)
+		assert.Equal(t, levels[v], level)
+	}
+	assert.Equal(t, []string{"control", "normal", "bulk1", "bulk2"}, got)
+
+	r.Overwrite(2, "a")
+	r.Overwrite(2, "b")
+	r.Overwrite(2, "c")
+	v, level, _ := r.Read()
+	assert.Equal(t, "b", v)
+	assert.Equal(t, 2, level)
+
+	r.Reset()
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestPriorityRingOf_Weighted(t *testing.T) {
+	r := NewPriorityRingOf[int](3, 8)
+	r.SetWeights(3, 2)
+	for i := 0; i < 6; i++ {
+		r.Write(0, 0)
+		r.Write(1, 1)
+		r.Write(2, 2)
+	}
+
+	var got []int
+	for {
+		_, level, err := r.Read()
+		if err != nil {
+			break
+		}
+		got = append(got, level)
+	}
+	assert.Equal(t, []int{
+		0, 0, 0, 1, 1, 2,
+		0, 0, 0, 1, 1, 2,
+		1, 1, 2,
+		2, 2, 2,
+	}, got)
+
+	// back to strict priority
+	r.Write(2, 2)
+	r.Write(0, 0)
+	r.SetWeights()
+	_, level, _ := r.Read()
+	assert.Equal(t, 0, level)
+}
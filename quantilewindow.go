@@ -0,0 +1,209 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"math"
+	"math/bits"
+)
+
+// QuantileWindow keeps the last size values and answers exact quantile queries over them.
+// Values are kept in insertion order in a ring buffer and in sorted order in an indexable skip list,
+// so Add and Quantile are O(log n). NaN values are not supported.
+// It is not thread-safe(goroutine-safe).
+type QuantileWindow struct {
+	rb     *RingBufferOf[float64]
+	size   int
+	sorted *skipList
+}
+
+// NewQuantileWindow creates a QuantileWindow over the last size values.
+func NewQuantileWindow(size int) *QuantileWindow {
+	if size < minBufferSize {
+		size = minBufferSize
+	}
+
+	return &QuantileWindow{
+		rb:     NewFixedOf[float64](size),
+		size:   size,
+		sorted: newSkipList(bits.Len(uint(size)) + 1),
+	}
+}
+
+// Add appends v to the window, evicting the oldest value when the window is full.
+func (w *QuantileWindow) Add(v float64) {
+	if w.rb.Len() >= w.size {
+		old, _ := w.rb.Peek()
+		w.sorted.remove(old)
+	}
+
+	w.rb.Overwrite(v)
+	w.sorted.insert(v)
+}
+
+// Quantile returns the q-quantile (0 <= q <= 1) of the values in the window,
+// linearly interpolated between the closest ranks.
+func (w *QuantileWindow) Quantile(q float64) (float64, error) {
+	n := w.sorted.n
+	if n == 0 {
+		return 0, ErrIsEmpty
+	}
+
+	if q <= 0 {
+		return w.sorted.get(0), nil
+	}
+	if q >= 1 {
+		return w.sorted.get(n - 1), nil
+	}
+
+	h := q * float64(n-1)
+	i := int(h)
+	v := w.sorted.get(i)
+	if f := h - float64(i); f > 0 {
+		v += f * (w.sorted.get(i+1) - v)
+	}
+	return v, nil
+}
+
+func (w *QuantileWindow) Median() (float64, error) {
+	return w.Quantile(0.5)
+}
+
+// Values returns the values in the window, from the oldest to the newest.
+func (w *QuantileWindow) Values() []float64 {
+	return w.rb.PeekAll()
+}
+
+// Len returns the number of values in the window.
+func (w *QuantileWindow) Len() int {
+	return w.rb.Len()
+}
+
+// Size returns the maximum number of values in the window.
+func (w *QuantileWindow) Size() int {
+	return w.size
+}
+
+func (w *QuantileWindow) Reset() {
+	w.rb.Reset()
+	w.sorted = newSkipList(len(w.sorted.head.next))
+}
+
+// skipList is an indexable skip list, each link stores the number of nodes it skips.
+type skipList struct {
+	head *skipNode
+	n    int
+	seed uint64
+}
+
+type skipNode struct {
+	v     float64
+	next  []*skipNode
+	width []int
+}
+
+func newSkipList(levels int) *skipList {
+	head := &skipNode{
+		v:     math.Inf(-1),
+		next:  make([]*skipNode, levels),
+		width: make([]int, levels),
+	}
+	for i := range head.width {
+		head.width[i] = 1
+	}
+
+	return &skipList{
+		head: head,
+		seed: 0x9e3779b97f4a7c15,
+	}
+}
+
+func (s *skipList) randomLevel() int {
+	// xorshift64
+	s.seed ^= s.seed << 13
+	s.seed ^= s.seed >> 7
+	s.seed ^= s.seed << 17
+
+	levels := len(s.head.next)
+	level := 1 + bits.TrailingZeros64(s.seed)
+	if level > levels {
+		return levels
+	}
+	return level
+}
+
+func (s *skipList) insert(v float64) {
+	levels := len(s.head.next)
+	chain := make([]*skipNode, levels)
+	steps := make([]int, levels)
+	node := s.head
+	for i := levels - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].v <= v {
+			steps[i] += node.width[i]
+			node = node.next[i]
+		}
+		chain[i] = node
+	}
+
+	d := s.randomLevel()
+	nn := &skipNode{
+		v:     v,
+		next:  make([]*skipNode, d),
+		width: make([]int, d),
+	}
+	step := 0
+	for i := 0; i < d; i++ {
+		prev := chain[i]
+		nn.next[i] = prev.next[i]
+		prev.next[i] = nn
+		nn.width[i] = prev.width[i] - step
+		prev.width[i] = step + 1
+		step += steps[i]
+	}
+	for i := d; i < levels; i++ {
+		chain[i].width[i]++
+	}
+	s.n++
+}
+
+func (s *skipList) remove(v float64) {
+	levels := len(s.head.next)
+	chain := make([]*skipNode, levels)
+	node := s.head
+	for i := levels - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].v < v {
+			node = node.next[i]
+		}
+		chain[i] = node
+	}
+
+	target := chain[0].next[0]
+	if target == nil || target.v != v {
+		return
+	}
+
+	d := len(target.next)
+	for i := 0; i < d; i++ {
+		prev := chain[i]
+		prev.width[i] += target.width[i] - 1
+		prev.next[i] = target.next[i]
+	}
+	for i := d; i < levels; i++ {
+		chain[i].width[i]--
+	}
+	s.n--
+}
+
+// get returns the i-th smallest value, 0 <= i < n.
+func (s *skipList) get(i int) float64 {
+	node := s.head
+	i++
+	for l := len(s.head.next) - 1; l >= 0; l-- {
+		for node.width[l] <= i {
+			i -= node.width[l]
+			node = node.next[l]
+		}
+	}
+	return node.v
+}
@@ -0,0 +1,74 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestQuantileWindow(t *testing.T) {
+	w := NewQuantileWindow(5)
+	_, err := w.Quantile(0.5)
+	assert.Equal(t, ErrIsEmpty, err)
+
+	for _, v := range []float64{5, 1, 4, 2, 3} {
+		w.Add(v)
+	}
+	v, err := w.Median()
+	assert.Nil(t, err)
+	assert.Equal(t, float64(3), v)
+	v, _ = w.Quantile(0)
+	assert.Equal(t, float64(1), v)
+	v, _ = w.Quantile(1)
+	assert.Equal(t, float64(5), v)
+	v, _ = w.Quantile(0.9)
+	assert.Equal(t, 4.6, v)
+
+	// evicts 5 and 1
+	w.Add(3)
+	w.Add(3)
+	assert.Equal(t, []float64{4, 2, 3, 3, 3}, w.Values())
+	v, _ = w.Quantile(0)
+	assert.Equal(t, float64(2), v)
+	v, _ = w.Quantile(1)
+	assert.Equal(t, float64(4), v)
+	v, _ = w.Median()
+	assert.Equal(t, float64(3), v)
+	assert.Equal(t, 5, w.Len())
+	assert.Equal(t, 5, w.Size())
+
+	w.Reset()
+	assert.Equal(t, 0, w.Len())
+	_, err = w.Median()
+	assert.Equal(t, ErrIsEmpty, err)
+	w.Add(1)
+	v, _ = w.Quantile(0.99)
+	assert.Equal(t, float64(1), v)
+}
+
+func TestQuantileWindow_Random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	w := NewQuantileWindow(100)
+	for i := 0; i < 2000; i++ {
+		w.Add(float64(rnd.Intn(50)))
+
+		values := w.Values()
+		sort.Float64s(values)
+		for _, q := range []float64{0, 0.5, 0.95, 0.99, 1} {
+			h := q * float64(len(values)-1)
+			j := int(h)
+			expected := values[j]
+			if j+1 < len(values) {
+				expected += (h - float64(j)) * (values[j+1] - values[j])
+			}
+			v, err := w.Quantile(q)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, v)
+		}
+	}
+}
@@ -2,11 +2,16 @@ package ringbuffer
 
 import (
 	"errors"
+	"sync"
 )
 
 const minBufferSize = 2
 
-var ErrIsEmpty = errors.New("ringbuffer is empty")
+var (
+	ErrIsEmpty     = errors.New("ringbuffer is empty")
+	ErrOutOfRange  = errors.New("ringbuffer index out of range")
+	ErrInvalidated = errors.New("ringbuffer cursor is invalidated")
+)
 
 type T interface{}
 
@@ -21,9 +26,31 @@ type RingBuffer struct {
 	size        int
 	maxSize     int
 	discards    uint64
-	r           int // read pointer
-	w           int // write pointer
+	seqs        []uint64 // sequences of the items in buf, nil while they are contiguous
+	base        uint64   // head + base is the sequence of the oldest item while seqs is nil
+	next        uint64   // sequence of the next written item
+	head        uint64   // number of items removed from the oldest end, for cursors and seqAt
+	mods        uint64   // modifications that invalidate cursors
+	r           int      // read pointer
+	w           int      // write pointer
 	onDiscards  func(interface{})
+
+	high      int // high watermark, 0 means disabled
+	low       int // low watermark
+	aboveHigh bool
+	onHigh    func(int)
+	onLow     func(int)
+
+	onGrow     func(old, new int) bool
+	onShrink   func(old, new int)
+	onReset    func()
+	onTruncate func(removed int)
+
+	sizer    func(interface{}) int
+	maxBytes int
+	bytes    int
+
+	pool *sync.Pool // reuses the underlying buffers, nil means disabled
 }
 
 func NewUnbounded(initialSize int) *RingBuffer {
@@ -62,7 +89,10 @@ func (r *RingBuffer) Read() (T, error) {
 	if r.r == r.size {
 		r.r = 0
 	}
+	r.head++
+	r.bytes -= r.sizeOf(v)
 
+	r.checkWatermarks()
 	return v, nil
 }
 
@@ -74,9 +104,12 @@ func (r *RingBuffer) RRead() (T, error) {
 	}
 	if r.w == 0 {
 		r.w = r.size - 1
-		return r.buf[r.w], nil
+	} else {
+		r.w--
 	}
-	r.w--
+	r.mods++
+	r.bytes -= r.sizeOf(r.buf[r.w])
+	r.checkWatermarks()
 	return r.buf[r.w], nil
 }
 
@@ -140,16 +173,21 @@ func (r *RingBuffer) LPeekN(n int) []T {
 }
 
 func (r *RingBuffer) Write(v T) {
-	if r.maxSize > 0 && r.Len() >= r.maxSize {
-		r.discards++
-		if r.onDiscards != nil {
-			r.onDiscards(v)
-		}
+	n := r.sizeOf(v)
+	if r.maxSize > 0 && r.Len() >= r.maxSize || r.maxBytes > 0 && r.bytes+n > r.maxBytes || !r.canGrow() {
+		r.discard(v)
 		return
 	}
 
 	r.buf[r.w] = v
+	if r.r == r.w && r.seqs == nil {
+		r.base = r.next - r.head
+		r.next++
+	} else {
+		r.stamp()
+	}
 	r.w++
+	r.bytes += n
 
 	if r.w == r.size {
 		r.w = 0
@@ -158,18 +196,35 @@ func (r *RingBuffer) Write(v T) {
 	if r.w == r.r { // full
 		r.grow()
 	}
+
+	r.checkWatermarks()
 }
 
 // Overwrite write, when the buffer reaches the maximum value, overwrite unread data.
+// With a byte budget, as many oldest items as needed are overwritten,
+// an item larger than the whole budget is discarded.
 func (r *RingBuffer) Overwrite(v T) {
-	if r.maxSize > 0 && r.Len() >= r.maxSize {
-		r.r++
-		if r.r == r.size {
-			r.r = 0
-		}
+	n := r.sizeOf(v)
+	if r.maxBytes > 0 && n > r.maxBytes {
+		r.discard(v)
+		return
+	}
+
+	for r.maxBytes > 0 && r.bytes+n > r.maxBytes {
+		r.evict()
+	}
+	if r.maxSize > 0 && r.Len() >= r.maxSize || !r.canGrow() {
+		r.evict()
 	}
 
 	r.buf[r.w] = v
+	if r.r == r.w && r.seqs == nil {
+		r.base = r.next - r.head
+		r.next++
+	} else {
+		r.stamp()
+	}
+	r.bytes += n
 	r.w++
 
 	if r.w == r.size {
@@ -179,51 +234,101 @@ func (r *RingBuffer) Overwrite(v T) {
 	if r.w == r.r { // full
 		r.grow()
 	}
+
+	r.checkWatermarks()
 }
 
-func (r *RingBuffer) grow() {
-	var size int
+// discard drops v instead of writing it, v still consumes a sequence.
+func (r *RingBuffer) discard(v T) {
+	r.next++
+	r.discards++
+	if r.onDiscards != nil {
+		r.onDiscards(v)
+	}
+}
+
+// evict drops the oldest unread item, the buffer must not be empty.
+func (r *RingBuffer) evict() {
+	r.bytes -= r.sizeOf(r.buf[r.r])
+	r.r++
+	if r.r == r.size {
+		r.r = 0
+	}
+	r.head++
+}
+
+// canGrow reports whether the next write is allowed to grow the buffer.
+// It is always true unless the next write fills the buffer and the OnGrow hook vetoes it.
+func (r *RingBuffer) canGrow() bool {
+	return r.onGrow == nil || r.askGrow()
+}
+
+func (r *RingBuffer) askGrow() bool {
+	return r.Len() < r.size-1 || r.onGrow(r.size, r.nextSize())
+}
+
+func (r *RingBuffer) nextSize() int {
 	if r.size < 1024 {
-		size = r.size * 2
-	} else {
-		size = r.size + r.size/4
+		return r.size * 2
 	}
+	return r.size + r.size/4
+}
 
-	buf := make([]T, size)
+func (r *RingBuffer) grow() {
+	size := r.nextSize()
+	buf, seqs := r.alloc(size)
 
 	copy(buf[0:], r.buf[r.r:])
 	copy(buf[r.size-r.r:], r.buf[0:r.r])
+	if r.seqs != nil {
+		copy(seqs[0:], r.seqs[r.r:])
+		copy(seqs[r.size-r.r:], r.seqs[0:r.r])
+	}
+	old, oldSeqs := r.buf, r.seqs
 
 	r.r = 0
 	r.w = r.size
 	r.size = size
 	r.buf = buf
+	r.seqs = seqs
+	r.release(old, oldSeqs)
 }
 
 // Truncate discards all but the first n unread bytes from the buffer
 // but continues to use the same allocated storage.
 func (r *RingBuffer) Truncate(n int) {
 	if n <= 0 {
+		removed := r.Len()
 		r.Reset()
+		r.truncated(removed)
 		return
 	}
 
-	if r.Len() <= n {
+	removed := r.Len() - n
+	if removed <= 0 {
 		return
 	}
+	r.head += uint64(removed)
+	if r.sizer != nil {
+		for i := 0; i < removed; i++ {
+			r.bytes -= r.sizer(r.at(i))
+		}
+	}
 
 	if r.size > n*2 {
-		data := r.RPeekN(n)
-		r.r = 0
-		r.w = n
-		r.size = n + 1
-		r.buf = make([]T, r.size)
-		copy(r.buf, data)
+		old := r.size
+		r.r = r.index(removed)
+		r.resize(n + 1)
+		if r.onShrink != nil {
+			r.onShrink(old, r.size)
+		}
+		r.checkWatermarks()
+		r.truncated(removed)
 		return
 	}
 
 	if r.w > r.r {
-		r.r = r.w - r.r - n
+		r.r = r.w - n
 	} else {
 		x := r.w - n
 		if x >= 0 {
@@ -232,6 +337,15 @@ func (r *RingBuffer) Truncate(n int) {
 			r.r = r.size + x
 		}
 	}
+
+	r.checkWatermarks()
+	r.truncated(removed)
+}
+
+func (r *RingBuffer) truncated(removed int) {
+	if removed > 0 && r.onTruncate != nil {
+		r.onTruncate(removed)
+	}
 }
 
 func (r *RingBuffer) IsEmpty() bool {
@@ -264,10 +378,23 @@ func (r *RingBuffer) Len() int {
 }
 
 func (r *RingBuffer) Reset() {
+	if r.pool != nil {
+		// keep the buffer for the next writes
+		r.Clear()
+		return
+	}
+
+	r.head += uint64(r.Len())
+	r.bytes = 0
 	r.r = 0
 	r.w = 0
 	r.size = r.initialSize
+	r.seqs = nil // no items, so they are contiguous again
 	r.buf = make([]T, r.initialSize)
+	if r.onReset != nil {
+		r.onReset()
+	}
+	r.checkWatermarks()
 }
 
 func (r *RingBuffer) SetMaxSize(n int) int {
@@ -288,3 +415,737 @@ func (r *RingBuffer) SetOnDiscards(fn func(interface{})) {
 		r.onDiscards = fn
 	}
 }
+
+// SetOnGrow registers a hook called before the underlying buffer grows.
+// Returning false vetoes the growth, as if maxSize was reached:
+// Write discards the item through onDiscards and Overwrite overwrites the oldest item.
+func (r *RingBuffer) SetOnGrow(fn func(old, new int) bool) {
+	if fn != nil {
+		r.onGrow = fn
+	}
+}
+
+// SetOnShrink registers a hook called after Truncate reallocates a smaller buffer.
+func (r *RingBuffer) SetOnShrink(fn func(old, new int)) {
+	if fn != nil {
+		r.onShrink = fn
+	}
+}
+
+// SetOnReset registers a hook called after the buffer is reset.
+func (r *RingBuffer) SetOnReset(fn func()) {
+	if fn != nil {
+		r.onReset = fn
+	}
+}
+
+// SetOnTruncate registers a hook called with the number of items removed by Truncate.
+func (r *RingBuffer) SetOnTruncate(fn func(removed int)) {
+	if fn != nil {
+		r.onTruncate = fn
+	}
+}
+
+// SetWatermarks registers backpressure callbacks.
+// onHigh is called once when Len() reaches high, onLow is called once when Len() drops back below low.
+// After onHigh, it will not be called again until onLow has been called, so a buffer hovering
+// around the line does not flap. A high value <= 0 disables the watermarks.
+func (r *RingBuffer) SetWatermarks(high, low int, onHigh, onLow func(int)) {
+	if high <= 0 {
+		r.high, r.low = 0, 0
+		r.onHigh, r.onLow = nil, nil
+		r.aboveHigh = false
+		return
+	}
+
+	if low > high {
+		low = high
+	}
+	r.high, r.low = high, low
+	r.onHigh, r.onLow = onHigh, onLow
+	r.aboveHigh = false
+	r.checkWatermarks()
+}
+
+// checkWatermarks is small enough to be inlined into Read and Write when the watermarks are disabled.
+func (r *RingBuffer) checkWatermarks() {
+	if r.high > 0 {
+		r.crossWatermarks()
+	}
+}
+
+func (r *RingBuffer) crossWatermarks() {
+	n := r.Len()
+	if !r.aboveHigh {
+		if n >= r.high {
+			r.aboveHigh = true
+			if r.onHigh != nil {
+				r.onHigh(n)
+			}
+		}
+		return
+	}
+
+	if n < r.low {
+		r.aboveHigh = false
+		if r.onLow != nil {
+			r.onLow(n)
+		}
+	}
+}
+
+// at returns the i-th unread item, 0 is the oldest.
+func (r *RingBuffer) at(i int) T {
+	return r.buf[r.index(i)]
+}
+
+// set replaces the i-th unread item.
+func (r *RingBuffer) set(i int, v T) {
+	r.buf[r.index(i)] = v
+}
+
+// move moves the j-th unread item and its sequence to the i-th position.
+func (r *RingBuffer) move(i, j int) {
+	i, j = r.index(i), r.index(j)
+	r.buf[i] = r.buf[j]
+	if r.seqs != nil {
+		r.seqs[i] = r.seqs[j]
+	}
+}
+
+// index returns the position in buf of the i-th unread item.
+func (r *RingBuffer) index(i int) int {
+	i += r.r
+	if i >= r.size {
+		i -= r.size
+	}
+	return i
+}
+
+// OldestSeq returns the sequence of the oldest unread item.
+// Every written item gets a monotonically increasing sequence, starting from 0.
+// Discarded writes consume a sequence too, the sequence of an erased item is never reused.
+func (r *RingBuffer) OldestSeq() (uint64, error) {
+	return r.SeqOf(0)
+}
+
+// NewestSeq returns the sequence of the newest unread item.
+func (r *RingBuffer) NewestSeq() (uint64, error) {
+	return r.SeqOf(r.Len() - 1)
+}
+
+// NextSeq returns the sequence the next written item will get.
+func (r *RingBuffer) NextSeq() uint64 {
+	return r.next
+}
+
+// SeqOf returns the sequence of the i-th unread item, 0 is the oldest.
+func (r *RingBuffer) SeqOf(i int) (uint64, error) {
+	if i < 0 || i >= r.Len() {
+		if r.r == r.w {
+			return 0, ErrIsEmpty
+		}
+		return 0, ErrOutOfRange
+	}
+	return r.seqAt(i), nil
+}
+
+// seqAt returns the sequence of the i-th unread item.
+func (r *RingBuffer) seqAt(i int) uint64 {
+	if r.seqs == nil {
+		return r.head + r.base + uint64(i)
+	}
+	return r.seqs[r.index(i)]
+}
+
+// stamp gives the next sequence to the item about to be written at r.w, after the newest one.
+// The sequences are only stored once the items stop being contiguous,
+// after an RRead, a discarded write or a removal in the middle.
+// Write and Overwrite handle the empty buffer case themselves, it is the hot path.
+func (r *RingBuffer) stamp() {
+	switch {
+	case r.seqs != nil:
+		r.seqs[r.w] = r.next
+	case r.r == r.w:
+		r.base = r.next - r.head
+	case r.seqAt(r.Len()) != r.next:
+		r.trackSeqs()
+		r.seqs[r.w] = r.next
+	}
+	r.next++
+}
+
+// trackSeqs starts storing the sequences of the items, before they stop being contiguous.
+func (r *RingBuffer) trackSeqs() {
+	if r.seqs != nil {
+		return
+	}
+
+	seqs := make([]uint64, r.size)
+	for i, n := 0, r.Len(); i < n; i++ {
+		seqs[r.index(i)] = r.seqAt(i)
+	}
+	r.seqs = seqs
+}
+
+// ReadFrom returns the unread items with a sequence from seq onwards, without reading them.
+// missed is the number of items from seq onwards that the caller can no longer see,
+// because they were discarded, read, overwritten, truncated or erased in between.
+func (r *RingBuffer) ReadFrom(seq uint64) (buf []T, missed uint64) {
+	if seq >= r.next {
+		return
+	}
+
+	for i, n := 0, r.Len(); i < n; i++ {
+		if r.seqAt(i) >= seq {
+			buf = append(buf, r.at(i))
+		}
+	}
+	missed = r.next - seq - uint64(len(buf))
+	return
+}
+
+// SetSizer sets the function that measures the size of an item, for SetMaxBytes and Bytes.
+func (r *RingBuffer) SetSizer(fn func(interface{}) int) {
+	if fn == nil {
+		return
+	}
+
+	r.sizer = fn
+	r.bytes = 0
+	for i, n := 0, r.Len(); i < n; i++ {
+		r.bytes += fn(r.at(i))
+	}
+}
+
+// SetMaxBytes limits the total size of the items measured by the sizer, 0 means unbounded.
+// Exceeding it, Write discards the item and Overwrite overwrites the oldest items.
+// The oldest items that do not fit into the new limit are truncated.
+func (r *RingBuffer) SetMaxBytes(n int) int {
+	if n <= 0 {
+		r.maxBytes = 0
+		return 0
+	}
+
+	r.maxBytes = n
+	if r.bytes > n {
+		keep, total := 0, 0
+		for i := r.Len() - 1; i >= 0; i-- {
+			total += r.sizeOf(r.at(i))
+			if total > n {
+				break
+			}
+			keep++
+		}
+		r.Truncate(keep)
+	}
+	return r.maxBytes
+}
+
+func (r *RingBuffer) MaxBytes() int {
+	return r.maxBytes
+}
+
+// Bytes returns the total size of the unread items, 0 without a sizer.
+func (r *RingBuffer) Bytes() int {
+	return r.bytes
+}
+
+func (r *RingBuffer) sizeOf(v T) int {
+	if r.sizer == nil {
+		return 0
+	}
+	return r.sizer(v)
+}
+
+// RemoveFunc removes the unread items for which del returns true, and returns how many were removed.
+// The remaining items keep their order and sequences, the freed slots are cleared.
+// Cursors are invalidated.
+func (r *RingBuffer) RemoveFunc(del func(T) bool) int {
+	n := r.Len()
+	j := 0
+	for i := 0; i < n; i++ {
+		v := r.at(i)
+		if del(v) {
+			// nothing has moved yet on the first removal
+			r.trackSeqs()
+			r.bytes -= r.sizeOf(v)
+			continue
+		}
+		if i != j {
+			r.move(j, i)
+		}
+		j++
+	}
+
+	if j == n {
+		return 0
+	}
+
+	for i := j; i < n; i++ {
+		r.set(i, nil)
+	}
+	r.w = r.index(j)
+	r.mods++
+	r.checkWatermarks()
+	return n - j
+}
+
+// Retain keeps only the unread items for which keep returns true, see RemoveFunc.
+func (r *RingBuffer) Retain(keep func(T) bool) {
+	r.RemoveFunc(func(v T) bool {
+		return !keep(v)
+	})
+}
+
+// DeleteAt removes the i-th unread item, 0 is the oldest, and returns it.
+// It shifts the shorter side of the buffer, the freed slot is cleared.
+// The other items keep their sequences, cursors are invalidated.
+func (r *RingBuffer) DeleteAt(i int) (T, error) {
+	n := r.Len()
+	if i < 0 || i >= n {
+		return nil, ErrOutOfRange
+	}
+
+	r.trackSeqs()
+	v := r.at(i)
+	if i < n/2 {
+		for k := i; k > 0; k-- {
+			r.move(k, k-1)
+		}
+		r.set(0, nil)
+		r.r = r.index(1)
+	} else {
+		for k := i; k < n-1; k++ {
+			r.move(k, k+1)
+		}
+		r.set(n-1, nil)
+		r.w = r.index(n - 1)
+	}
+
+	r.bytes -= r.sizeOf(v)
+	r.mods++
+	r.checkWatermarks()
+	return v, nil
+}
+
+// regions returns the unread items as two slices of buf, the older part first.
+// It does not allocate, the second slice is empty unless the items wrap around.
+func (r *RingBuffer) regions() (a, b []T) {
+	if r.w >= r.r {
+		return r.buf[r.r:r.w], nil
+	}
+	return r.buf[r.r:], r.buf[:r.w]
+}
+
+// IndexFunc returns the index of the first unread item satisfying f, 0 is the oldest, or -1 if none do.
+func (r *RingBuffer) IndexFunc(f func(T) bool) int {
+	a, b := r.regions()
+	for i, v := range a {
+		if f(v) {
+			return i
+		}
+	}
+	for i, v := range b {
+		if f(v) {
+			return len(a) + i
+		}
+	}
+	return -1
+}
+
+// LastIndexFunc returns the index of the last unread item satisfying f, searching from the newest, or -1 if none do.
+func (r *RingBuffer) LastIndexFunc(f func(T) bool) int {
+	a, b := r.regions()
+	for i := len(b) - 1; i >= 0; i-- {
+		if f(b[i]) {
+			return len(a) + i
+		}
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		if f(a[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ContainsFunc reports whether at least one unread item satisfies f.
+func (r *RingBuffer) ContainsFunc(f func(T) bool) bool {
+	return r.IndexFunc(f) >= 0
+}
+
+// InsertAt inserts v before the i-th unread item, 0 is the oldest and Len() appends like Write.
+// It shifts the shorter side of the buffer and grows it if needed.
+// Exceeding maxSize, v will be discarded like Write.
+// v gets the next sequence like a written item, the other items keep theirs,
+// so a ReadFrom caller gets v once and no item twice. Cursors are invalidated.
+func (r *RingBuffer) InsertAt(i int, v T) error {
+	n := r.Len()
+	if i < 0 || i > n {
+		return ErrOutOfRange
+	}
+
+	size := r.sizeOf(v)
+	if r.maxSize > 0 && n >= r.maxSize || r.maxBytes > 0 && r.bytes+size > r.maxBytes || !r.canGrow() {
+		r.discard(v)
+		return nil
+	}
+
+	r.insert(i, v, size)
+	return nil
+}
+
+// OverwriteAt inserts v before the i-th unread item like InsertAt,
+// when the buffer reaches the maximum value, the oldest items are overwritten first
+// and i is moved back by the number of overwritten items, down to 0.
+func (r *RingBuffer) OverwriteAt(i int, v T) error {
+	if i < 0 || i > r.Len() {
+		return ErrOutOfRange
+	}
+
+	size := r.sizeOf(v)
+	if r.maxBytes > 0 && size > r.maxBytes {
+		r.discard(v)
+		return nil
+	}
+
+	for r.maxBytes > 0 && r.bytes+size > r.maxBytes {
+		r.evict()
+		i--
+	}
+	if r.maxSize > 0 && r.Len() >= r.maxSize || !r.canGrow() {
+		r.evict()
+		i--
+	}
+	if i < 0 {
+		i = 0
+	}
+
+	r.insert(i, v, size)
+	return nil
+}
+
+func (r *RingBuffer) insert(i int, v T, size int) {
+	n := r.Len()
+	if i == n {
+		r.stamp()
+	} else {
+		r.trackSeqs()
+	}
+	if i < n/2 {
+		r.r--
+		if r.r < 0 {
+			r.r = r.size - 1
+		}
+		for k := 0; k < i; k++ {
+			r.move(k, k+1)
+		}
+	} else {
+		r.w++
+		if r.w == r.size {
+			r.w = 0
+		}
+		for k := n; k > i; k-- {
+			r.move(k, k-1)
+		}
+	}
+	r.set(i, v)
+	if i < n {
+		r.seqs[r.index(i)] = r.next
+		r.next++
+	}
+	r.bytes += size
+
+	if r.w == r.r { // full
+		r.grow()
+	}
+
+	r.mods++
+	r.checkWatermarks()
+}
+
+// LowerBound returns the index of the first unread item not less than target, or Len() if none is.
+// The unread items must be sorted in ascending order by cmp,
+// which returns a negative number if a < b, 0 if a == b and a positive number if a > b.
+func (r *RingBuffer) LowerBound(target T, cmp func(a, b T) int) int {
+	i, j := 0, r.Len()
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if cmp(r.at(h), target) < 0 {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	return i
+}
+
+// UpperBound returns the index of the first unread item greater than target, or Len() if none is.
+// The unread items must be sorted in ascending order by cmp, see LowerBound.
+func (r *RingBuffer) UpperBound(target T, cmp func(a, b T) int) int {
+	i, j := 0, r.Len()
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if cmp(r.at(h), target) <= 0 {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	return i
+}
+
+// BinarySearchFunc searches for target in the unread items sorted in ascending order by cmp, see LowerBound.
+// It returns the index where target is found, or would be inserted with InsertAt,
+// and whether it is found.
+func (r *RingBuffer) BinarySearchFunc(target T, cmp func(a, b T) int) (int, bool) {
+	i := r.LowerBound(target, cmp)
+	return i, i < r.Len() && cmp(r.at(i), target) == 0
+}
+
+// ReadUntil reads the unread items from the oldest one until stop returns true,
+// the item stop returns true for stays unread.
+func (r *RingBuffer) ReadUntil(stop func(T) bool) (buf []T) {
+	for r.r != r.w {
+		v := r.buf[r.r]
+		if stop(v) {
+			break
+		}
+		buf = append(buf, v)
+		r.evict()
+	}
+	r.checkWatermarks()
+	return
+}
+
+// DiscardWhile drops the unread items from the oldest one while pred returns true,
+// and returns how many were dropped.
+func (r *RingBuffer) DiscardWhile(pred func(T) bool) int {
+	n := 0
+	for r.r != r.w && pred(r.buf[r.r]) {
+		r.evict()
+		n++
+	}
+	r.checkWatermarks()
+	return n
+}
+
+// Clone returns an independent buffer with the same unread items, capacity, limits, sizer and counters.
+// A pooled buffer gives a pooled clone with its own pool.
+// The callbacks are copied too if withCallbacks is true, cursors are not.
+func (r *RingBuffer) Clone(withCallbacks ...bool) *RingBuffer {
+	c := &RingBuffer{
+		buf:         make([]T, r.size),
+		initialSize: r.initialSize,
+		size:        r.size,
+		maxSize:     r.maxSize,
+		discards:    r.discards,
+		next:        r.next,
+		base:        r.base,
+		head:        r.head,
+		high:        r.high,
+		low:         r.low,
+		aboveHigh:   r.aboveHigh,
+		sizer:       r.sizer,
+		maxBytes:    r.maxBytes,
+		bytes:       r.bytes,
+	}
+	if r.seqs != nil {
+		c.seqs = make([]uint64, r.size)
+	}
+	c.w = r.copyItems(c.buf, c.seqs)
+	if r.pool != nil {
+		c.pool = &sync.Pool{}
+	}
+
+	if len(withCallbacks) > 0 && withCallbacks[0] {
+		c.onDiscards = r.onDiscards
+		c.onHigh = r.onHigh
+		c.onLow = r.onLow
+		c.onGrow = r.onGrow
+		c.onShrink = r.onShrink
+		c.onReset = r.onReset
+		c.onTruncate = r.onTruncate
+	}
+	return c
+}
+
+// CopyTo replaces the unread items of dst with the unread items of r, r is not changed.
+// dst keeps its own settings: its items are truncated as by Truncate(0),
+// then the items of r are written as by Write, exceeding its limits they are discarded.
+func (r *RingBuffer) CopyTo(dst *RingBuffer) {
+	if dst == r {
+		return
+	}
+
+	dst.Truncate(0)
+	a, b := r.regions()
+	for _, v := range a {
+		dst.Write(v)
+	}
+	for _, v := range b {
+		dst.Write(v)
+	}
+}
+
+// EqualFunc reports whether r and other hold the same unread items in the same order,
+// using eq to compare them, regardless of their capacities and positions in the underlying buffers.
+func (r *RingBuffer) EqualFunc(other *RingBuffer, eq func(a, b T) bool) bool {
+	if r.Len() != other.Len() {
+		return false
+	}
+
+	for i, n := 0, r.Len(); i < n; i++ {
+		if !eq(r.at(i), other.at(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Reserve makes sure that at least n more items can be written without growing the buffer,
+// reallocating it to the exact size needed at once. It never reserves beyond maxSize.
+// The OnGrow hook can veto it, then it does nothing.
+func (r *RingBuffer) Reserve(n int) {
+	if r.maxSize > 0 && r.Len()+n > r.maxSize {
+		n = r.maxSize - r.Len()
+	}
+
+	size := r.Len() + n + 1
+	if size <= r.size {
+		return
+	}
+	if r.onGrow != nil && !r.onGrow(r.size, size) {
+		return
+	}
+	r.resize(size)
+}
+
+// Resize reallocates the buffer to exactly capacity, see Capacity, keeping the order of the items.
+// One slot is always free, so up to capacity-1 items fit without growing.
+// If there are more, the oldest ones are dropped and reported through onDiscards.
+func (r *RingBuffer) Resize(capacity int) {
+	if capacity < minBufferSize {
+		capacity = minBufferSize
+	}
+
+	for r.Len() > capacity-1 {
+		v := r.buf[r.r]
+		r.evict()
+		r.discards++
+		if r.onDiscards != nil {
+			r.onDiscards(v)
+		}
+	}
+
+	old := r.size
+	r.resize(capacity)
+	if capacity < old && r.onShrink != nil {
+		r.onShrink(old, capacity)
+	}
+	r.checkWatermarks()
+}
+
+// resize reallocates the buffer to size, it must be greater than Len().
+func (r *RingBuffer) resize(size int) {
+	buf, seqs := r.alloc(size)
+	n := r.copyItems(buf, seqs)
+	old, oldSeqs := r.buf, r.seqs
+
+	r.r = 0
+	r.w = n
+	r.size = size
+	r.buf = buf
+	r.seqs = seqs
+	r.release(old, oldSeqs)
+}
+
+// copyItems copies the unread items and their sequences to the start of buf and seqs, and returns how many.
+func (r *RingBuffer) copyItems(buf []T, seqs []uint64) int {
+	a, b := r.regions()
+	n := copy(buf, a)
+	n += copy(buf[n:], b)
+	if r.seqs == nil {
+		return n
+	}
+	if r.w >= r.r {
+		copy(seqs, r.seqs[r.r:r.w])
+	} else {
+		k := copy(seqs, r.seqs[r.r:])
+		copy(seqs[k:], r.seqs[:r.w])
+	}
+	return n
+}
+
+// Clear discards all unread items like Reset, but keeps the underlying buffer and its capacity.
+// The slots are zeroed, so the items can be garbage collected.
+func (r *RingBuffer) Clear() {
+	for i := range r.buf {
+		r.buf[i] = nil
+	}
+
+	r.head += uint64(r.Len())
+	r.bytes = 0
+	r.r = 0
+	r.w = 0
+	if r.onReset != nil {
+		r.onReset()
+	}
+	r.checkWatermarks()
+}
+
+// SetPooled enables or disables reusing the underlying buffers through a sync.Pool of this buffer.
+// The buffers released by grow and Resize are zeroed and kept for the next allocations,
+// a reused buffer may have a larger capacity than Capacity reports.
+// Reset (and Truncate(0)) keeps the underlying buffer like Clear, so a grow and Reset cycle stops allocating.
+// Shrinking by Truncate, SetMaxSize or Resize bypasses the pool, so the memory is freed.
+func (r *RingBuffer) SetPooled(enabled bool) {
+	if !enabled {
+		r.pool = nil
+		return
+	}
+	if r.pool == nil {
+		r.pool = &sync.Pool{}
+	}
+}
+
+// slots is a pair of underlying buffers kept in the pool.
+type slots struct {
+	buf  []T
+	seqs []uint64
+}
+
+// alloc returns a zeroed buffer of size and, if the sequences are stored, a buffer for them,
+// from the pool if enabled. Shrinking skips the pool, a pooled buffer could be larger than the current one.
+func (r *RingBuffer) alloc(size int) (buf []T, seqs []uint64) {
+	if r.pool != nil && size >= len(r.buf) {
+		if p, ok := r.pool.Get().(*slots); ok && cap(p.buf) >= size {
+			buf, seqs = p.buf[:size], p.seqs
+		}
+	}
+	if buf == nil {
+		buf = make([]T, size)
+	}
+	if r.seqs == nil {
+		return buf, nil
+	}
+	if cap(seqs) < size {
+		return buf, make([]uint64, size)
+	}
+	return buf, seqs[:size]
+}
+
+// release puts the replaced buf and seqs back into the pool if enabled, they must not be used afterwards.
+// Buffers larger than the new one are dropped, so shrinking frees their memory.
+func (r *RingBuffer) release(buf []T, seqs []uint64) {
+	if r.pool == nil || len(buf) > len(r.buf) {
+		return
+	}
+
+	buf = buf[:cap(buf)]
+	for i := range buf {
+		buf[i] = nil
+	}
+	r.pool.Put(&slots{buf: buf, seqs: seqs})
+}
@@ -288,3 +288,975 @@ func TestRingBufferOf_Overwrite(t *testing.T) {
 	rb.Truncate(2)
 	assert.Equal(t, []int{10, 11}, rb.PeekAll())
 }
+
+func TestRingBufferOf_Truncate(t *testing.T) {
+	rb := NewOf[int](8)
+	for i := 1; i <= 7; i++ {
+		rb.Write(i)
+	}
+	_, _ = rb.Read()
+	_, _ = rb.Read()
+
+	// r > 0 and w > r, keeps the storage
+	rb.Truncate(4)
+	assert.Equal(t, 4, rb.Len())
+	assert.Equal(t, 8, rb.Capacity())
+	assert.Equal(t, []int{4, 5, 6, 7}, rb.PeekAll())
+
+	rb.Write(8)
+	assert.Equal(t, []int{4, 5, 6, 7, 8}, rb.PeekAll())
+}
+
+func TestRingBufferOf_Watermarks(t *testing.T) {
+	rb := NewUnboundedOf[int](4)
+	var highs, lows []int
+	rb.SetWatermarks(5, 2, func(n int) {
+		highs = append(highs, n)
+	}, func(n int) {
+		lows = append(lows, n)
+	})
+
+	for i := 0; i < 4; i++ {
+		rb.Write(i)
+	}
+	assert.Equal(t, 0, len(highs))
+
+	rb.Write(4)
+	assert.Equal(t, []int{5}, highs)
+
+	// hovering around the high watermark does not flap
+	for i := 0; i < 3; i++ {
+		_, _ = rb.Read()
+		rb.Write(i)
+	}
+	assert.Equal(t, []int{5}, highs)
+	assert.Equal(t, 0, len(lows))
+
+	for rb.Len() > 2 {
+		_, _ = rb.Read()
+	}
+	assert.Equal(t, 0, len(lows))
+
+	_, _ = rb.RRead()
+	assert.Equal(t, []int{1}, lows)
+
+	rb.Reset()
+	assert.Equal(t, []int{1}, lows)
+
+	for i := 0; i < 5; i++ {
+		rb.Overwrite(i)
+	}
+	assert.Equal(t, []int{5, 5}, highs)
+
+	rb.Truncate(1)
+	assert.Equal(t, []int{1, 1}, lows)
+
+	// already above the high watermark
+	for i := 0; i < 5; i++ {
+		rb.Write(i)
+	}
+	rb.SetWatermarks(3, 10, func(n int) {
+		highs = append(highs, n)
+	}, nil)
+	assert.Equal(t, []int{5, 5, 5, 6}, highs)
+
+	rb.SetWatermarks(0, 0, nil, nil)
+	rb.Reset()
+	for i := 0; i < 10; i++ {
+		rb.Write(i)
+	}
+	assert.Equal(t, []int{5, 5, 5, 6}, highs)
+}
+
+func TestRingBufferOf_Hooks(t *testing.T) {
+	rb := NewUnboundedOf[int](2)
+	var grows [][2]int
+	allowGrow := true
+	rb.SetOnGrow(func(old, new int) bool {
+		grows = append(grows, [2]int{old, new})
+		return allowGrow
+	})
+	var discards []int
+	rb.SetOnDiscards(func(v int) {
+		discards = append(discards, v)
+	})
+
+	for i := 0; i < 4; i++ {
+		rb.Write(i)
+	}
+	assert.Equal(t, [][2]int{{2, 4}, {4, 8}}, grows)
+	assert.Equal(t, 8, rb.Capacity())
+
+	// veto acts like maxSize reached
+	allowGrow = false
+	for i := 4; i < 10; i++ {
+		rb.Write(i)
+	}
+	assert.Equal(t, 8, rb.Capacity())
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, rb.PeekAll())
+	assert.Equal(t, []int{7, 8, 9}, discards)
+	assert.Equal(t, uint64(3), rb.Discards())
+
+	rb.Overwrite(10)
+	assert.Equal(t, 8, rb.Capacity())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 10}, rb.PeekAll())
+
+	var shrinks [][2]int
+	rb.SetOnShrink(func(old, new int) {
+		shrinks = append(shrinks, [2]int{old, new})
+	})
+	var truncates []int
+	rb.SetOnTruncate(func(removed int) {
+		truncates = append(truncates, removed)
+	})
+	resets := 0
+	rb.SetOnReset(func() {
+		resets++
+	})
+
+	_, _ = rb.Read()
+	_, _ = rb.Read()
+	rb.Truncate(4)
+	assert.Equal(t, []int{4, 5, 6, 10}, rb.PeekAll())
+	assert.Equal(t, []int{1}, truncates)
+	assert.Equal(t, 0, len(shrinks))
+
+	rb.Truncate(1)
+	assert.Equal(t, []int{10}, rb.PeekAll())
+	assert.Equal(t, []int{1, 3}, truncates)
+	assert.Equal(t, [][2]int{{8, 2}}, shrinks)
+
+	rb.Truncate(5)
+	assert.Equal(t, []int{1, 3}, truncates)
+
+	rb.Truncate(0)
+	assert.Equal(t, []int{1, 3, 1}, truncates)
+	assert.Equal(t, 1, resets)
+
+	rb.Reset()
+	assert.Equal(t, []int{1, 3, 1}, truncates)
+	assert.Equal(t, 2, resets)
+}
+
+func TestRingBufferOf_Seq(t *testing.T) {
+	rb := NewOf[int](2, 4)
+	_, err := rb.OldestSeq()
+	assert.Equal(t, ErrIsEmpty, err)
+	_, err = rb.NewestSeq()
+	assert.Equal(t, ErrIsEmpty, err)
+	assert.Equal(t, uint64(0), rb.NextSeq())
+
+	// grows
+	for i := 0; i < 4; i++ {
+		rb.Write(i)
+	}
+	oldest, _ := rb.OldestSeq()
+	newest, _ := rb.NewestSeq()
+	assert.Equal(t, uint64(0), oldest)
+	assert.Equal(t, uint64(3), newest)
+
+	// discarded writes consume a sequence
+	rb.Write(4)
+	assert.Equal(t, uint64(5), rb.NextSeq())
+
+	_, _ = rb.Read()
+	seq, err := rb.SeqOf(0)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), seq)
+	seq, _ = rb.SeqOf(2)
+	assert.Equal(t, uint64(3), seq)
+	_, err = rb.SeqOf(3)
+	assert.Equal(t, ErrOutOfRange, err)
+	_, err = rb.SeqOf(-1)
+	assert.Equal(t, ErrOutOfRange, err)
+
+	// overwrites
+	rb.Write(5)
+	rb.Overwrite(6)
+	rb.Overwrite(7)
+	assert.Equal(t, []int{3, 5, 6, 7}, rb.PeekAll())
+	oldest, _ = rb.OldestSeq()
+	newest, _ = rb.NewestSeq()
+	assert.Equal(t, uint64(3), oldest)
+	assert.Equal(t, uint64(7), newest)
+
+	buf, missed := rb.ReadFrom(1)
+	assert.Equal(t, []int{3, 5, 6, 7}, buf)
+	assert.Equal(t, uint64(3), missed)
+	buf, missed = rb.ReadFrom(6)
+	assert.Equal(t, []int{6, 7}, buf)
+	assert.Equal(t, uint64(0), missed)
+	buf, missed = rb.ReadFrom(8)
+	assert.Nil(t, buf)
+	assert.Equal(t, uint64(0), missed)
+	buf, _ = rb.ReadFrom(1 << 63)
+	assert.Nil(t, buf)
+
+	rb.Truncate(1)
+	oldest, _ = rb.OldestSeq()
+	assert.Equal(t, uint64(7), oldest)
+	buf, missed = rb.ReadFrom(4)
+	assert.Equal(t, []int{7}, buf)
+	assert.Equal(t, uint64(3), missed)
+
+	// RRead never gives back a sequence
+	_, _ = rb.RRead()
+	assert.Equal(t, uint64(8), rb.NextSeq())
+	rb.Write(8)
+	newest, _ = rb.NewestSeq()
+	assert.Equal(t, uint64(8), newest)
+	buf, missed = rb.ReadFrom(7)
+	assert.Equal(t, []int{8}, buf)
+	assert.Equal(t, uint64(1), missed)
+
+	rb.Write(9)
+	rb.Reset()
+	assert.Equal(t, uint64(10), rb.NextSeq())
+	rb.Write(10)
+	oldest, _ = rb.OldestSeq()
+	assert.Equal(t, uint64(10), oldest)
+
+	// a reader that has seen the erased item gets the next one
+	rb = NewOf[int](4)
+	rb.Write(1)
+	rb.Write(2)
+	buf, _ = rb.ReadFrom(0)
+	assert.Equal(t, []int{1, 2}, buf)
+	_, _ = rb.RRead()
+	rb.Write(3)
+	buf, missed = rb.ReadFrom(2)
+	assert.Equal(t, []int{3}, buf)
+	assert.Equal(t, uint64(0), missed)
+	buf, missed = rb.ReadFrom(0)
+	assert.Equal(t, []int{1, 3}, buf)
+	assert.Equal(t, uint64(1), missed)
+	seq, _ = rb.SeqOf(1)
+	assert.Equal(t, uint64(2), seq)
+
+	// a reader learns about the writes discarded at maxSize
+	rb = NewOf[int](2, 3)
+	for i := 1; i <= 6; i++ {
+		rb.Write(i)
+	}
+	assert.Equal(t, uint64(3), rb.Discards())
+	buf, missed = rb.ReadFrom(1)
+	assert.Equal(t, []int{2, 3}, buf)
+	assert.Equal(t, uint64(3), missed)
+	_, _ = rb.Read()
+	rb.Write(7)
+	buf, missed = rb.ReadFrom(3)
+	assert.Equal(t, []int{7}, buf)
+	assert.Equal(t, uint64(3), missed)
+}
+
+func TestRingBufferOf_LazySeq(t *testing.T) {
+	rb := NewOf[int](2, 8)
+	for i := 0; i < 6; i++ {
+		rb.Write(i)
+	}
+	_, _ = rb.Read()
+	rb.Overwrite(6)
+	rb.Truncate(4)
+
+	// contiguous sequences are not stored
+	assert.Nil(t, rb.seqs)
+	seq, _ := rb.OldestSeq()
+	assert.Equal(t, uint64(3), seq)
+	seq, _ = rb.NewestSeq()
+	assert.Equal(t, uint64(6), seq)
+
+	// a gap after the newest item starts storing them
+	_, _ = rb.RRead()
+	assert.Nil(t, rb.seqs)
+	rb.Write(7)
+	assert.NotNil(t, rb.seqs)
+	buf, missed := rb.ReadFrom(3)
+	assert.Equal(t, []int{3, 4, 5, 7}, buf)
+	assert.Equal(t, uint64(1), missed)
+
+	// an empty buffer is contiguous again
+	rb.Reset()
+	assert.Nil(t, rb.seqs)
+	rb.Write(8)
+	rb.Write(9)
+	rb.Write(10)
+	seq, _ = rb.OldestSeq()
+	assert.Equal(t, uint64(8), seq)
+
+	// a removal in the middle starts storing them
+	rb.RemoveFunc(func(v int) bool {
+		return v == 9
+	})
+	assert.NotNil(t, rb.seqs)
+	buf, missed = rb.ReadFrom(8)
+	assert.Equal(t, []int{8, 10}, buf)
+	assert.Equal(t, uint64(1), missed)
+}
+
+func TestRingBufferOf_MaxBytes(t *testing.T) {
+	rb := NewUnboundedOf[string](2)
+	rb.Write("abc")
+	assert.Equal(t, 0, rb.Bytes())
+
+	rb.SetSizer(func(v string) int {
+		return len(v)
+	})
+	assert.Equal(t, 3, rb.Bytes())
+
+	var discards []string
+	rb.SetOnDiscards(func(v string) {
+		discards = append(discards, v)
+	})
+	assert.Equal(t, 10, rb.SetMaxBytes(10))
+	rb.Write("defg")
+	rb.Write("hijk")
+	assert.Equal(t, []string{"abc", "defg"}, rb.PeekAll())
+	assert.Equal(t, []string{"hijk"}, discards)
+	assert.Equal(t, uint64(1), rb.Discards())
+	assert.Equal(t, 7, rb.Bytes())
+
+	// overwrites as many oldest items as needed
+	rb.Overwrite("lmnopqr")
+	assert.Equal(t, []string{"lmnopqr"}, rb.PeekAll())
+	assert.Equal(t, 7, rb.Bytes())
+	rb.Overwrite("st")
+	rb.Overwrite("u")
+	assert.Equal(t, []string{"lmnopqr", "st", "u"}, rb.PeekAll())
+	assert.Equal(t, 10, rb.Bytes())
+
+	// larger than the whole budget
+	rb.Overwrite("0123456789x")
+	assert.Equal(t, []string{"hijk", "0123456789x"}, discards)
+	assert.Equal(t, 10, rb.Bytes())
+
+	v, _ := rb.Read()
+	assert.Equal(t, "lmnopqr", v)
+	assert.Equal(t, 3, rb.Bytes())
+	_, _ = rb.RRead()
+	assert.Equal(t, 2, rb.Bytes())
+
+	rb.Write("vw")
+	rb.Write("xyz")
+	rb.Truncate(2)
+	assert.Equal(t, []string{"vw", "xyz"}, rb.PeekAll())
+	assert.Equal(t, 5, rb.Bytes())
+
+	// the oldest items that do not fit are truncated
+	assert.Equal(t, 4, rb.SetMaxBytes(4))
+	assert.Equal(t, []string{"xyz"}, rb.PeekAll())
+	assert.Equal(t, 3, rb.Bytes())
+	assert.Equal(t, 4, rb.MaxBytes())
+
+	rb.Reset()
+	assert.Equal(t, 0, rb.Bytes())
+	assert.Equal(t, 0, rb.SetMaxBytes(0))
+	for i := 0; i < 10; i++ {
+		rb.Write("0123456789")
+	}
+	assert.Equal(t, 100, rb.Bytes())
+}
+
+func TestRingBufferOf_RemoveFunc(t *testing.T) {
+	rb := NewOf[int](4, 8)
+	for i := 0; i < 10; i++ {
+		rb.Overwrite(i)
+	}
+	// wrapped around
+	assert.Equal(t, []int{2, 3, 4, 5, 6, 7, 8, 9}, rb.PeekAll())
+	used := countUsedOf(rb.buf)
+	rb.SetSizer(func(int) int {
+		return 1
+	})
+
+	removed := rb.RemoveFunc(func(v int) bool {
+		return v%3 == 0
+	})
+	assert.Equal(t, 3, removed)
+	assert.Equal(t, []int{2, 4, 5, 7, 8}, rb.PeekAll())
+	assert.Equal(t, 5, rb.Bytes())
+	assert.Equal(t, 0, rb.RemoveFunc(func(v int) bool {
+		return v > 100
+	}))
+
+	// freed slots are cleared
+	assert.Equal(t, used-3, countUsedOf(rb.buf))
+
+	rb.Retain(func(v int) bool {
+		return v%2 == 0
+	})
+	assert.Equal(t, []int{2, 4, 8}, rb.PeekAll())
+
+	// writes continue after the compacted items
+	rb.Write(10)
+	rb.Write(11)
+	assert.Equal(t, []int{2, 4, 8, 10, 11}, rb.PeekAll())
+	assert.Equal(t, 5, rb.Bytes())
+}
+
+func TestRingBufferOf_DeleteAt(t *testing.T) {
+	rb := NewOf[int](4, 8)
+	for i := 0; i < 10; i++ {
+		rb.Overwrite(i)
+	}
+	c := rb.Cursor()
+	used := countUsedOf(rb.buf)
+
+	// front side
+	v, err := rb.DeleteAt(1)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, v)
+	assert.Equal(t, []int{2, 4, 5, 6, 7, 8, 9}, rb.PeekAll())
+	_, ok := c.Next()
+	assert.False(t, ok)
+	assert.Equal(t, ErrInvalidated, c.Err())
+
+	// back side
+	v, err = rb.DeleteAt(5)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, v)
+	assert.Equal(t, []int{2, 4, 5, 6, 7, 9}, rb.PeekAll())
+
+	v, _ = rb.DeleteAt(0)
+	assert.Equal(t, 2, v)
+	v, _ = rb.DeleteAt(4)
+	assert.Equal(t, 9, v)
+	assert.Equal(t, []int{4, 5, 6, 7}, rb.PeekAll())
+
+	_, err = rb.DeleteAt(4)
+	assert.Equal(t, ErrOutOfRange, err)
+	_, err = rb.DeleteAt(-1)
+	assert.Equal(t, ErrOutOfRange, err)
+
+	assert.Equal(t, used-4, countUsedOf(rb.buf))
+
+	for i := 0; i < 4; i++ {
+		rb.Write(i + 10)
+	}
+	assert.Equal(t, []int{4, 5, 6, 7, 10, 11, 12, 13}, rb.PeekAll())
+}
+
+func TestRingBufferOf_RemoveSeq(t *testing.T) {
+	rb := NewOf[int](4)
+	for i := 0; i < 10; i++ {
+		rb.Write(i)
+	}
+	// the items keep their sequences, which equal their values here
+	checkSeqs := func() {
+		for i, v := range rb.PeekAll() {
+			seq, _ := rb.SeqOf(i)
+			assert.Equal(t, uint64(v), seq)
+		}
+	}
+
+	assert.Equal(t, 4, rb.RemoveFunc(func(v int) bool {
+		return v%3 == 0
+	}))
+	checkSeqs()
+	// front and back side
+	_, _ = rb.DeleteAt(1)
+	_, _ = rb.DeleteAt(3)
+	assert.Equal(t, []int{1, 4, 5, 8}, rb.PeekAll())
+	checkSeqs()
+
+	// a reader resuming at 5 neither skips nor repeats items
+	buf, missed := rb.ReadFrom(5)
+	assert.Equal(t, []int{5, 8}, buf)
+	assert.Equal(t, uint64(3), missed)
+	rb.Write(10)
+	buf, missed = rb.ReadFrom(9)
+	assert.Equal(t, []int{10}, buf)
+	assert.Equal(t, uint64(1), missed)
+}
+
+func countUsedOf[T comparable](buf []T) int {
+	var zero T
+	n := 0
+	for _, v := range buf {
+		if v != zero {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRingBufferOf_IndexFunc(t *testing.T) {
+	rb := NewOf[int](4, 8)
+	assert.Equal(t, -1, rb.IndexFunc(func(int) bool { return true }))
+	assert.Equal(t, -1, rb.LastIndexFunc(func(int) bool { return true }))
+	assert.False(t, rb.ContainsFunc(func(int) bool { return true }))
+	assert.Equal(t, -1, Index(rb, 0))
+
+	for i := 0; i < 12; i++ {
+		rb.Overwrite(i % 5)
+	}
+	// wrapped around
+	assert.Equal(t, []int{4, 0, 1, 2, 3, 4, 0, 1}, rb.PeekAll())
+
+	odd := func(v int) bool {
+		return v%2 == 1
+	}
+	assert.Equal(t, 2, rb.IndexFunc(odd))
+	assert.Equal(t, 7, rb.LastIndexFunc(odd))
+	assert.True(t, rb.ContainsFunc(odd))
+	assert.False(t, rb.ContainsFunc(func(v int) bool {
+		return v > 4
+	}))
+
+	assert.Equal(t, 0, Index(rb, 4))
+	assert.Equal(t, 2, Index(rb, 1))
+	assert.Equal(t, -1, Index(rb, 5))
+	assert.True(t, Contains(rb, 3))
+	assert.False(t, Contains(rb, 5))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		rb.IndexFunc(odd)
+		rb.LastIndexFunc(odd)
+		Index(rb, 5)
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+func TestRingBufferOf_InsertAt(t *testing.T) {
+	rb := NewOf[int](4, 6)
+	assert.Equal(t, ErrOutOfRange, rb.InsertAt(1, 1))
+	assert.Equal(t, ErrOutOfRange, rb.InsertAt(-1, 1))
+
+	assert.Nil(t, rb.InsertAt(0, 3))
+	assert.Nil(t, rb.InsertAt(1, 5))
+	// front side
+	assert.Nil(t, rb.InsertAt(0, 1))
+	assert.Equal(t, []int{1, 3, 5}, rb.PeekAll())
+	// back side, grows
+	assert.Nil(t, rb.InsertAt(2, 4))
+	assert.Equal(t, []int{1, 3, 4, 5}, rb.PeekAll())
+	assert.Equal(t, 8, rb.Capacity())
+	assert.Nil(t, rb.InsertAt(1, 2))
+	assert.Nil(t, rb.InsertAt(5, 6))
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, rb.PeekAll())
+
+	// exceeding maxSize, discarded like Write
+	var discards []int
+	rb.SetOnDiscards(func(v int) {
+		discards = append(discards, v)
+	})
+	assert.Nil(t, rb.InsertAt(3, 0))
+	assert.Equal(t, []int{0}, discards)
+	assert.Equal(t, uint64(1), rb.Discards())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, rb.PeekAll())
+
+	// the oldest items are overwritten first
+	assert.Nil(t, rb.OverwriteAt(3, 30))
+	assert.Equal(t, []int{2, 3, 30, 4, 5, 6}, rb.PeekAll())
+	assert.Nil(t, rb.OverwriteAt(0, 10))
+	assert.Equal(t, []int{10, 3, 30, 4, 5, 6}, rb.PeekAll())
+	assert.Equal(t, ErrOutOfRange, rb.OverwriteAt(7, 1))
+
+	// the inserted item gets the next sequence, the discarded one consumed one
+	seq, _ := rb.OldestSeq()
+	assert.Equal(t, uint64(8), seq)
+	assert.Equal(t, uint64(9), rb.NextSeq())
+}
+
+func TestRingBufferOf_InsertSeq(t *testing.T) {
+	rb := NewOf[int](4)
+	for i := 0; i < 6; i++ {
+		rb.Write(i)
+	}
+	buf, _ := rb.ReadFrom(0)
+	assert.Equal(t, 6, len(buf))
+
+	// front and back side, late items
+	assert.Nil(t, rb.InsertAt(1, 10))
+	assert.Nil(t, rb.InsertAt(5, 11))
+	assert.Equal(t, []int{0, 10, 1, 2, 3, 11, 4, 5}, rb.PeekAll())
+	for i, want := range []uint64{0, 6, 1, 2, 3, 7, 4, 5} {
+		seq, _ := rb.SeqOf(i)
+		assert.Equal(t, want, seq)
+	}
+	newest, _ := rb.NewestSeq()
+	assert.Equal(t, uint64(5), newest)
+
+	// a reader that has seen everything gets only the inserted items
+	buf, missed := rb.ReadFrom(6)
+	assert.Equal(t, []int{10, 11}, buf)
+	assert.Equal(t, uint64(0), missed)
+	rb.Write(12)
+	buf, missed = rb.ReadFrom(8)
+	assert.Equal(t, []int{12}, buf)
+	assert.Equal(t, uint64(0), missed)
+}
+
+func TestRingBufferOf_InsertAt_Wrap(t *testing.T) {
+	rb := NewOf[int](8)
+	rb.SetSizer(func(int) int {
+		return 1
+	})
+	c := rb.Cursor()
+	for i := 0; i < 6; i++ {
+		rb.Write(i)
+	}
+	for i := 0; i < 5; i++ {
+		_, _ = rb.Read()
+	}
+	for i := 6; i < 10; i++ {
+		rb.Write(i)
+	}
+	// r is at 5, w is at 2
+	assert.Nil(t, rb.InsertAt(1, 50))
+	assert.Nil(t, rb.InsertAt(4, 80))
+	assert.Equal(t, []int{5, 50, 6, 7, 80, 8, 9}, rb.PeekAll())
+	assert.Equal(t, 8, rb.Capacity())
+	assert.Equal(t, 7, rb.Bytes())
+
+	// fills the buffer and grows
+	assert.Nil(t, rb.InsertAt(7, 10))
+	assert.Equal(t, 16, rb.Capacity())
+	assert.Equal(t, []int{5, 50, 6, 7, 80, 8, 9, 10}, rb.PeekAll())
+
+	_, ok := c.Next()
+	assert.False(t, ok)
+	assert.Equal(t, ErrInvalidated, c.Err())
+}
+
+func TestRingBufferOf_BinarySearchFunc(t *testing.T) {
+	cmp := func(a, b int) int {
+		return a - b
+	}
+	rb := NewOf[int](8)
+	i, ok := rb.BinarySearchFunc(1, cmp)
+	assert.Equal(t, 0, i)
+	assert.False(t, ok)
+
+	for i := 0; i < 6; i++ {
+		rb.Write(i)
+	}
+	for i := 0; i < 5; i++ {
+		_, _ = rb.Read()
+	}
+	for _, v := range []int{6, 6, 6, 8, 10} {
+		rb.Write(v)
+	}
+	// wrapped around
+	assert.Equal(t, []int{5, 6, 6, 6, 8, 10}, rb.PeekAll())
+
+	i, ok = rb.BinarySearchFunc(6, cmp)
+	assert.Equal(t, 1, i)
+	assert.True(t, ok)
+	i, ok = rb.BinarySearchFunc(7, cmp)
+	assert.Equal(t, 4, i)
+	assert.False(t, ok)
+	i, ok = rb.BinarySearchFunc(11, cmp)
+	assert.Equal(t, 6, i)
+	assert.False(t, ok)
+	i, ok = rb.BinarySearchFunc(1, cmp)
+	assert.Equal(t, 0, i)
+	assert.False(t, ok)
+
+	assert.Equal(t, 1, rb.LowerBound(6, cmp))
+	assert.Equal(t, 4, rb.UpperBound(6, cmp))
+	assert.Equal(t, 6, rb.UpperBound(10, cmp))
+	assert.Equal(t, 0, rb.UpperBound(4, cmp))
+}
+
+func TestRingBufferOf_ReadUntil(t *testing.T) {
+	rb := NewOf[int](4)
+	rb.SetSizer(func(int) int {
+		return 1
+	})
+	assert.Nil(t, rb.ReadUntil(func(int) bool { return false }))
+	assert.Equal(t, 0, rb.DiscardWhile(func(int) bool { return true }))
+
+	for i := 0; i < 10; i++ {
+		rb.Write(i)
+	}
+	buf := rb.ReadUntil(func(v int) bool {
+		return v >= 3
+	})
+	assert.Equal(t, []int{0, 1, 2}, buf)
+	seq, _ := rb.OldestSeq()
+	assert.Equal(t, uint64(3), seq)
+
+	n := rb.DiscardWhile(func(v int) bool {
+		return v < 7
+	})
+	assert.Equal(t, 4, n)
+	assert.Equal(t, []int{7, 8, 9}, rb.PeekAll())
+	assert.Equal(t, 3, rb.Bytes())
+	assert.Equal(t, uint64(0), rb.Discards())
+
+	buf = rb.ReadUntil(func(int) bool {
+		return false
+	})
+	assert.Equal(t, []int{7, 8, 9}, buf)
+	assert.True(t, rb.IsEmpty())
+	assert.Equal(t, 0, rb.Bytes())
+}
+
+func TestRingBufferOf_Clone(t *testing.T) {
+	rb := NewOf[int](4, 6)
+	rb.SetSizer(func(int) int {
+		return 1
+	})
+	var discards []int
+	rb.SetOnDiscards(func(v int) {
+		discards = append(discards, v)
+	})
+	for i := 0; i < 8; i++ {
+		rb.Write(i)
+	}
+	_, _ = rb.Read()
+	rb.Write(8)
+	// wrapped around
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 8}, rb.PeekAll())
+
+	c := rb.Clone()
+	assert.Equal(t, rb.PeekAll(), c.PeekAll())
+	assert.Equal(t, rb.Capacity(), c.Capacity())
+	assert.Equal(t, rb.MaxSize(), c.MaxSize())
+	assert.Equal(t, rb.Discards(), c.Discards())
+	assert.Equal(t, rb.Bytes(), c.Bytes())
+	assert.Equal(t, rb.NextSeq(), c.NextSeq())
+	assert.True(t, EqualFunc(rb, c, func(a, b int) bool {
+		return a == b
+	}))
+
+	// independent, without callbacks
+	c.Write(9)
+	_, _ = c.Read()
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 8}, rb.PeekAll())
+	assert.Equal(t, []int{2, 3, 4, 5, 8}, c.PeekAll())
+	assert.Equal(t, []int{6, 7}, discards)
+	assert.Equal(t, uint64(3), c.Discards())
+	assert.False(t, EqualFunc(rb, c, func(a, b int) bool {
+		return a == b
+	}))
+
+	c = rb.Clone(true)
+	c.Write(10)
+	assert.Equal(t, []int{6, 7, 10}, discards)
+}
+
+func TestRingBufferOf_CopyTo(t *testing.T) {
+	rb := NewOf[int](4)
+	for i := 0; i < 6; i++ {
+		rb.Write(i)
+	}
+	_, _ = rb.Read()
+	_, _ = rb.Read()
+
+	dst := NewOf[int](2, 3)
+	dst.Write(100)
+	var truncates []int
+	dst.SetOnTruncate(func(removed int) {
+		truncates = append(truncates, removed)
+	})
+	rb.CopyTo(dst)
+	assert.Equal(t, []int{2, 3, 4}, dst.PeekAll())
+	assert.Equal(t, uint64(1), dst.Discards())
+	assert.Equal(t, []int{1}, truncates)
+	assert.Equal(t, []int{2, 3, 4, 5}, rb.PeekAll())
+
+	dst.SetMaxSize(0)
+	rb.CopyTo(dst)
+	assert.True(t, EqualFunc(rb, dst, func(a, b int) bool {
+		return a == b
+	}))
+	rb.CopyTo(rb)
+	assert.Equal(t, []int{2, 3, 4, 5}, rb.PeekAll())
+
+	assert.True(t, EqualFunc(NewOf[int](2), NewOf[int](8), func(a, b int) bool {
+		return false
+	}))
+}
+
+func TestRingBufferOf_Reserve(t *testing.T) {
+	rb := NewOf[int](4)
+	rb.Write(0)
+	rb.Reserve(2)
+	assert.Equal(t, 4, rb.Capacity())
+
+	rb.Reserve(100)
+	assert.Equal(t, 102, rb.Capacity())
+	for i := 1; i <= 100; i++ {
+		rb.Write(i)
+	}
+	assert.Equal(t, 102, rb.Capacity())
+	assert.Equal(t, 101, rb.Len())
+	v, _ := rb.Peek()
+	assert.Equal(t, 0, v)
+
+	// never beyond maxSize
+	rb = NewOf[int](2, 10)
+	rb.Write(0)
+	rb.Reserve(100)
+	assert.Equal(t, 11, rb.Capacity())
+
+	// vetoed
+	rb = NewOf[int](2)
+	rb.SetOnGrow(func(old, new int) bool {
+		return false
+	})
+	rb.Reserve(10)
+	assert.Equal(t, 2, rb.Capacity())
+}
+
+func TestRingBufferOf_Resize(t *testing.T) {
+	rb := NewOf[int](4)
+	var discards []int
+	rb.SetOnDiscards(func(v int) {
+		discards = append(discards, v)
+	})
+	var shrinks []int
+	rb.SetOnShrink(func(old, new int) {
+		shrinks = append(shrinks, old, new)
+	})
+	for i := 0; i < 6; i++ {
+		rb.Write(i)
+	}
+	_, _ = rb.Read()
+	for i := 6; i < 9; i++ {
+		rb.Write(i)
+	}
+	// wrapped around
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, rb.PeekAll())
+	assert.Equal(t, 16, rb.Capacity())
+
+	rb.Resize(20)
+	assert.Equal(t, 20, rb.Capacity())
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8}, rb.PeekAll())
+	assert.Nil(t, shrinks)
+
+	rb.Resize(6)
+	assert.Equal(t, 6, rb.Capacity())
+	assert.Equal(t, []int{4, 5, 6, 7, 8}, rb.PeekAll())
+	assert.Equal(t, []int{1, 2, 3}, discards)
+	assert.Equal(t, uint64(3), rb.Discards())
+	assert.Equal(t, []int{20, 6}, shrinks)
+	seq, _ := rb.OldestSeq()
+	assert.Equal(t, uint64(4), seq)
+
+	rb.Resize(0)
+	assert.Equal(t, minBufferSize, rb.Capacity())
+	assert.Equal(t, []int{8}, rb.PeekAll())
+	rb.Write(9)
+	assert.Equal(t, []int{8, 9}, rb.PeekAll())
+}
+
+func TestRingBufferOf_Clear(t *testing.T) {
+	rb := NewOf[int](2)
+	resets := 0
+	rb.SetOnReset(func() {
+		resets++
+	})
+	for i := 1; i <= 10; i++ {
+		rb.Write(i)
+	}
+	capacity := rb.Capacity()
+	buf := rb.buf
+
+	rb.Clear()
+	assert.True(t, rb.IsEmpty())
+	assert.Equal(t, capacity, rb.Capacity())
+	assert.Equal(t, 1, resets)
+	assert.Equal(t, uint64(10), rb.NextSeq())
+	assert.Equal(t, 0, countUsedOf(rb.buf))
+	assert.True(t, &buf[0] == &rb.buf[0])
+
+	rb.Write(11)
+	assert.Equal(t, []int{11}, rb.PeekAll())
+}
+
+func TestRingBufferOf_SetPooled(t *testing.T) {
+	rb := NewOf[int](2)
+	rb.SetPooled(true)
+	for round := 1; round <= 3; round++ {
+		for i := 1; i <= 100; i++ {
+			rb.Write(i)
+		}
+		assert.Equal(t, 100, rb.Len())
+		v, _ := rb.Peek()
+		assert.Equal(t, 1, v)
+		v, _ = rb.RPeek()
+		assert.Equal(t, 100, v)
+
+		rb.Truncate(10)
+		assert.Equal(t, []int{91, 92, 93, 94, 95, 96, 97, 98, 99, 100}, rb.PeekAll())
+		rb.Resize(4)
+		assert.Equal(t, []int{98, 99, 100}, rb.PeekAll())
+		rb.Reserve(20)
+		assert.Equal(t, []int{98, 99, 100}, rb.PeekAll())
+
+		// Reset keeps the buffer
+		size := rb.Capacity()
+		rb.Reset()
+		assert.True(t, rb.IsEmpty())
+		assert.Equal(t, size, rb.Capacity())
+		// reused buffers are zeroed
+		assert.Equal(t, 0, countUsedOf(rb.buf[:cap(rb.buf)]))
+	}
+
+	// shrinking bypasses the pool, the larger buffers are freed
+	for i := 1; i <= 100; i++ {
+		rb.Write(i)
+	}
+	rb.Truncate(10)
+	assert.Equal(t, 11, cap(rb.buf))
+	rb.SetMaxSize(4)
+	assert.Equal(t, 5, cap(rb.buf))
+	rb.Reset()
+	assert.Equal(t, 5, cap(rb.buf))
+
+	// a clone is pooled with its own pool
+	rb.Write(1)
+	c := rb.Clone()
+	assert.True(t, c.pool != nil && c.pool != rb.pool)
+	assert.Equal(t, []int{1}, c.PeekAll())
+
+	rb.SetPooled(false)
+	rb.Write(1)
+	rb.Write(2)
+	rb.Reset()
+	assert.Equal(t, 2, cap(rb.buf))
+	assert.Nil(t, rb.Clone().pool)
+
+	// a grow and Reset cycle allocates only without the pool
+	allocs := func(pooled bool) float64 {
+		rb := NewOf[int](64)
+		rb.SetPooled(pooled)
+		return testing.AllocsPerRun(100, func() {
+			for i := 0; i < 100; i++ {
+				rb.Write(i)
+			}
+			rb.Reset()
+		})
+	}
+	assert.Equal(t, float64(0), allocs(true))
+	assert.True(t, allocs(false) > 0)
+}
+
+func BenchmarkRingBufferOf_WriteRead(b *testing.B) {
+	rb := NewOf[int](64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rb.Write(i)
+		_, _ = rb.Read()
+	}
+}
+
+func BenchmarkRingBufferOf_Reset(b *testing.B) {
+	bench := func(pooled bool) func(b *testing.B) {
+		return func(b *testing.B) {
+			rb := NewOf[int](64)
+			rb.SetPooled(pooled)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for j := 0; j < 100; j++ {
+					rb.Write(j)
+				}
+				rb.Reset()
+			}
+		}
+	}
+	b.Run("make", bench(false))
+	b.Run("pooled", bench(true))
+}
@@ -0,0 +1,171 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"math"
+)
+
+// Number is a constraint that permits any integer or floating-point type.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// RollingWindow keeps the last size values and their aggregates.
+// Sum, Mean, Variance and StdDev are O(1), Min and Max are O(1) amortised.
+// The sums are kept relative to a shift close to the values, and recomputed from the values
+// once per turn of the window, so neither a large offset nor a long stream loses precision.
+// It is not thread-safe(goroutine-safe).
+type RollingWindow[N Number] struct {
+	rb      *RingBufferOf[N]
+	size    int
+	shift   float64
+	sum     float64          // sum of v - shift
+	sumSq   float64          // sum of (v - shift)²
+	evicted int              // evictions since the sums were recomputed
+	mins    *RingBufferOf[N] // monotonic increasing deque
+	maxs    *RingBufferOf[N] // monotonic decreasing deque
+}
+
+// NewRollingWindow creates a RollingWindow over the last size values.
+func NewRollingWindow[N Number](size int) *RollingWindow[N] {
+	if size < minBufferSize {
+		size = minBufferSize
+	}
+
+	return &RollingWindow[N]{
+		rb:   NewFixedOf[N](size),
+		size: size,
+		mins: NewUnboundedOf[N](size),
+		maxs: NewUnboundedOf[N](size),
+	}
+}
+
+// Add appends v to the window, evicting the oldest value when the window is full.
+func (w *RollingWindow[N]) Add(v N) {
+	if w.rb.Len() >= w.size {
+		old, _ := w.rb.Peek()
+		d := float64(old) - w.shift
+		w.sum -= d
+		w.sumSq -= d * d
+		w.evicted++
+		if x, _ := w.mins.Peek(); x == old {
+			_, _ = w.mins.Read()
+		}
+		if x, _ := w.maxs.Peek(); x == old {
+			_, _ = w.maxs.Read()
+		}
+	}
+
+	if w.rb.IsEmpty() {
+		w.shift = float64(v)
+	}
+	w.rb.Overwrite(v)
+	d := float64(v) - w.shift
+	w.sum += d
+	w.sumSq += d * d
+	if w.evicted >= w.size {
+		w.recompute()
+	}
+
+	for !w.mins.IsEmpty() {
+		if x, _ := w.mins.RPeek(); x <= v {
+			break
+		}
+		_, _ = w.mins.RRead()
+	}
+	w.mins.Write(v)
+
+	for !w.maxs.IsEmpty() {
+		if x, _ := w.maxs.RPeek(); x >= v {
+			break
+		}
+		_, _ = w.maxs.RRead()
+	}
+	w.maxs.Write(v)
+}
+
+// Values returns the values in the window, from the oldest to the newest.
+func (w *RollingWindow[N]) Values() []N {
+	return w.rb.PeekAll()
+}
+
+// Len returns the number of values in the window.
+func (w *RollingWindow[N]) Len() int {
+	return w.rb.Len()
+}
+
+// Size returns the maximum number of values in the window.
+func (w *RollingWindow[N]) Size() int {
+	return w.size
+}
+
+func (w *RollingWindow[N]) Sum() float64 {
+	return w.sum + float64(w.rb.Len())*w.shift
+}
+
+// Mean returns the arithmetic mean, or 0 if the window is empty.
+func (w *RollingWindow[N]) Mean() float64 {
+	n := w.rb.Len()
+	if n == 0 {
+		return 0
+	}
+	return w.shift + w.sum/float64(n)
+}
+
+// Variance returns the population variance, or 0 if the window is empty.
+func (w *RollingWindow[N]) Variance() float64 {
+	n := w.rb.Len()
+	if n == 0 {
+		return 0
+	}
+
+	mean := w.sum / float64(n)
+	v := w.sumSq/float64(n) - mean*mean
+	if v < 0 {
+		// rounding errors
+		return 0
+	}
+	return v
+}
+
+// StdDev returns the population standard deviation, or 0 if the window is empty.
+func (w *RollingWindow[N]) StdDev() float64 {
+	return math.Sqrt(w.Variance())
+}
+
+func (w *RollingWindow[N]) Min() (N, error) {
+	return w.mins.Peek()
+}
+
+func (w *RollingWindow[N]) Max() (N, error) {
+	return w.maxs.Peek()
+}
+
+func (w *RollingWindow[N]) Reset() {
+	w.rb.Reset()
+	w.mins.Reset()
+	w.maxs.Reset()
+	w.shift = 0
+	w.sum = 0
+	w.sumSq = 0
+	w.evicted = 0
+}
+
+// recompute shifts the sums to the current mean and recomputes them from the values.
+func (w *RollingWindow[N]) recompute() {
+	w.shift = w.Mean()
+	w.sum, w.sumSq = 0, 0
+	a, b := w.rb.regions()
+	for _, vs := range [2][]N{a, b} {
+		for _, v := range vs {
+			d := float64(v) - w.shift
+			w.sum += d
+			w.sumSq += d * d
+		}
+	}
+	w.evicted = 0
+}
@@ -0,0 +1,106 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestRollingWindow(t *testing.T) {
+	w := NewRollingWindow[int](3)
+	_, err := w.Min()
+	assert.Equal(t, ErrIsEmpty, err)
+	_, err = w.Max()
+	assert.Equal(t, ErrIsEmpty, err)
+	assert.Equal(t, float64(0), w.Mean())
+	assert.Equal(t, float64(0), w.Variance())
+
+	w.Add(2)
+	w.Add(4)
+	w.Add(4)
+	assert.Equal(t, 3, w.Len())
+	assert.Equal(t, float64(10), w.Sum())
+	lo, _ := w.Min()
+	hi, _ := w.Max()
+	assert.Equal(t, 2, lo)
+	assert.Equal(t, 4, hi)
+
+	// evicts 2
+	w.Add(4)
+	assert.Equal(t, []int{4, 4, 4}, w.Values())
+	assert.Equal(t, float64(4), w.Mean())
+	assert.Equal(t, float64(0), w.Variance())
+	lo, _ = w.Min()
+	assert.Equal(t, 4, lo)
+
+	// evicts one of the duplicated 4
+	w.Add(1)
+	w.Add(7)
+	assert.Equal(t, []int{4, 1, 7}, w.Values())
+	lo, _ = w.Min()
+	hi, _ = w.Max()
+	assert.Equal(t, 1, lo)
+	assert.Equal(t, 7, hi)
+	assert.Equal(t, float64(4), w.Mean())
+	assert.Equal(t, float64(6), w.Variance())
+	assert.Equal(t, math.Sqrt(6), w.StdDev())
+
+	w.Add(5)
+	w.Add(6)
+	lo, _ = w.Min()
+	hi, _ = w.Max()
+	assert.Equal(t, 5, lo)
+	assert.Equal(t, 7, hi)
+	assert.Equal(t, 3, w.Size())
+
+	w.Reset()
+	assert.Equal(t, 0, w.Len())
+	assert.Equal(t, float64(0), w.Sum())
+	_, err = w.Max()
+	assert.Equal(t, ErrIsEmpty, err)
+}
+
+func TestRollingWindow_Float(t *testing.T) {
+	w := NewRollingWindow[float64](100)
+	for i := 0; i < 1000; i++ {
+		w.Add(float64(i % 10))
+	}
+	assert.Equal(t, 100, w.Len())
+	assert.True(t, math.Abs(w.Mean()-4.5) < 1e-9)
+	assert.True(t, math.Abs(w.Variance()-8.25) < 1e-9)
+	lo, _ := w.Min()
+	hi, _ := w.Max()
+	assert.Equal(t, float64(0), lo)
+	assert.Equal(t, float64(9), hi)
+}
+
+func TestRollingWindow_Precision(t *testing.T) {
+	// large offset
+	w := NewRollingWindow[float64](4)
+	for i := 0; i < 1000; i++ {
+		w.Add(1e9 + float64(i%2))
+	}
+	assert.Equal(t, 0.25, w.Variance())
+	assert.Equal(t, 1e9+0.5, w.Mean())
+	assert.Equal(t, 4e9+2, w.Sum())
+
+	wi := NewRollingWindow[int64](4)
+	for i := 0; i < 1000; i++ {
+		wi.Add(1e12 + int64(i%2))
+	}
+	assert.Equal(t, 0.25, wi.Variance())
+
+	// long stream, a huge value has left the window
+	w = NewRollingWindow[float64](4)
+	w.Add(1e15)
+	for i := 0; i < 1000; i++ {
+		w.Add(0.1)
+	}
+	assert.True(t, math.Abs(w.Sum()-0.4) < 1e-12)
+	assert.True(t, math.Abs(w.Mean()-0.1) < 1e-12)
+	assert.True(t, w.Variance() < 1e-12)
+}
@@ -0,0 +1,189 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ShardedRingOf spreads writes over several RingBufferOf shards, each with its own lock,
+// to reduce lock contention with many producers.
+// Items are ordered within a shard only: items written with the same key, or through
+// the same ShardProducer, are read in write order, but there is no order between shards.
+// Write spreads consecutive items over the shards, so they are not ordered, even from a single goroutine.
+// maxSize applies to each shard.
+// It is thread-safe(goroutine-safe).
+type ShardedRingOf[T any] struct {
+	next   uint64 // round-robin counter for Write, first for 64-bit alignment
+	read   uint64 // round-robin counter for ReadAny
+	pins   uint64 // round-robin counter for Producer
+	shards []ringShard[T]
+}
+
+// ShardProducer writes to the single shard it is pinned to, so its items are read in write order.
+// It is thread-safe(goroutine-safe), concurrent writes through it are ordered by the shard lock.
+type ShardProducer[T any] struct {
+	r     *ShardedRingOf[T]
+	shard int
+}
+
+type ringShard[T any] struct {
+	mu sync.Mutex
+	rb *RingBufferOf[T]
+	_  [64]byte // avoid false sharing
+}
+
+// NewShardedRingOf creates a ShardedRingOf with n shards,
+// initialSize and maxBufferSize have the same meaning as in NewOf, for each shard.
+func NewShardedRingOf[T any](n, initialSize int, maxBufferSize ...int) *ShardedRingOf[T] {
+	if n < 1 {
+		n = 1
+	}
+
+	r := &ShardedRingOf[T]{
+		shards: make([]ringShard[T], n),
+	}
+	for i := range r.shards {
+		r.shards[i].rb = NewOf[T](initialSize, maxBufferSize...)
+	}
+	return r
+}
+
+// Write writes v to the next shard in round-robin order, consecutive items go to different shards.
+// Use WriteKey or a Producer to keep the order of the items.
+func (r *ShardedRingOf[T]) Write(v T) {
+	i := atomic.AddUint64(&r.next, 1)
+	r.writeShard(int(i%uint64(len(r.shards))), v)
+}
+
+// Producer returns a ShardProducer pinned to the next shard in round-robin order,
+// each producer should get its own to keep its items in order and spread the load.
+func (r *ShardedRingOf[T]) Producer() *ShardProducer[T] {
+	i := atomic.AddUint64(&r.pins, 1)
+	return &ShardProducer[T]{
+		r:     r,
+		shard: int(i % uint64(len(r.shards))),
+	}
+}
+
+// Write writes v to the shard of the producer.
+func (p *ShardProducer[T]) Write(v T) {
+	p.r.writeShard(p.shard, v)
+}
+
+// Shard returns the index of the shard the producer writes to.
+func (p *ShardProducer[T]) Shard() int {
+	return p.shard
+}
+
+// WriteKey writes v to the shard chosen by the hash of key,
+// items with the same key are kept in order.
+func (r *ShardedRingOf[T]) WriteKey(key string, v T) {
+	r.writeShard(r.ShardOf(key), v)
+}
+
+// ShardOf returns the index of the shard that WriteKey uses for key.
+func (r *ShardedRingOf[T]) ShardOf(key string) int {
+	// FNV-1a
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return int(h % uint64(len(r.shards)))
+}
+
+func (r *ShardedRingOf[T]) writeShard(i int, v T) {
+	s := &r.shards[i]
+	s.mu.Lock()
+	s.rb.Write(v)
+	s.mu.Unlock()
+}
+
+// ReadAny reads an item from the first non-empty shard,
+// starting from a different shard on each call.
+func (r *ShardedRingOf[T]) ReadAny() (T, error) {
+	n := len(r.shards)
+	start := int(atomic.AddUint64(&r.read, 1) % uint64(n))
+	for i := 0; i < n; i++ {
+		s := &r.shards[(start+i)%n]
+		s.mu.Lock()
+		v, err := s.rb.Read()
+		s.mu.Unlock()
+		if err == nil {
+			return v, nil
+		}
+	}
+
+	var t T
+	return t, ErrIsEmpty
+}
+
+// DrainAll reads all items, shard by shard, keeping the order within each shard.
+func (r *ShardedRingOf[T]) DrainAll() (buf []T) {
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.Lock()
+		for {
+			v, err := s.rb.Read()
+			if err != nil {
+				break
+			}
+			buf = append(buf, v)
+		}
+		s.mu.Unlock()
+	}
+	return
+}
+
+// Shards returns the number of shards.
+func (r *ShardedRingOf[T]) Shards() int {
+	return len(r.shards)
+}
+
+// Len returns the total number of items of all shards.
+func (r *ShardedRingOf[T]) Len() int {
+	n := 0
+	for i := range r.shards {
+		n += r.ShardLen(i)
+	}
+	return n
+}
+
+// ShardLen returns the number of items of the i-th shard.
+func (r *ShardedRingOf[T]) ShardLen(i int) int {
+	s := &r.shards[i]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rb.Len()
+}
+
+// Discards returns the total number of discarded items of all shards.
+func (r *ShardedRingOf[T]) Discards() uint64 {
+	var n uint64
+	for i := range r.shards {
+		n += r.ShardDiscards(i)
+	}
+	return n
+}
+
+// ShardDiscards returns the number of discarded items of the i-th shard.
+func (r *ShardedRingOf[T]) ShardDiscards(i int) uint64 {
+	s := &r.shards[i]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rb.Discards()
+}
+
+// SetOnDiscards registers the callback for items discarded by any shard,
+// it may be called concurrently from different shards.
+func (r *ShardedRingOf[T]) SetOnDiscards(fn func(T)) {
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.Lock()
+		s.rb.SetOnDiscards(fn)
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,121 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestShardedRingOf(t *testing.T) {
+	r := NewShardedRingOf[int](4, 2, 3)
+	assert.Equal(t, 4, r.Shards())
+	_, err := r.ReadAny()
+	assert.Equal(t, ErrIsEmpty, err)
+
+	for i := 0; i < 8; i++ {
+		r.Write(i)
+	}
+	assert.Equal(t, 8, r.Len())
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, 2, r.ShardLen(i))
+	}
+
+	// same key, same shard, in order
+	shard := r.ShardOf("k")
+	assert.Equal(t, shard, r.ShardOf("k"))
+	discards := 0
+	r.SetOnDiscards(func(int) {
+		discards++
+	})
+	r.WriteKey("k", 100)
+	r.WriteKey("k", 101)
+	assert.Equal(t, 3, r.ShardLen(shard))
+	assert.Equal(t, uint64(1), r.ShardDiscards(shard))
+	assert.Equal(t, uint64(1), r.Discards())
+	assert.Equal(t, 1, discards)
+
+	v, err := r.ReadAny()
+	assert.Nil(t, err)
+	assert.Equal(t, 8, r.Len())
+
+	all := r.DrainAll()
+	assert.Equal(t, 8, len(all))
+	assert.Equal(t, 0, r.Len())
+	all = append(all, v)
+	sort.Ints(all)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 100}, all)
+}
+
+func TestShardedRingOf_Concurrent(t *testing.T) {
+	r := NewShardedRingOf[string](8, 16)
+	const producers, n = 16, 1000
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			key := strconv.Itoa(p)
+			for i := 0; i < n; i++ {
+				r.WriteKey(key, key+":"+strconv.Itoa(i))
+			}
+		}(p)
+	}
+	wg.Wait()
+	assert.Equal(t, producers*n, r.Len())
+
+	// ordered per key
+	next := make(map[string]int)
+	for _, v := range r.DrainAll() {
+		key, idx, _ := strings.Cut(v, ":")
+		i, _ := strconv.Atoi(idx)
+		assert.Equal(t, next[key], i)
+		next[key]++
+	}
+	assert.Equal(t, producers, len(next))
+}
+
+func TestShardedRingOf_Producer(t *testing.T) {
+	r := NewShardedRingOf[string](4, 16)
+	const producers, n = 8, 1000
+
+	// producers are spread over the shards
+	ps := make([]*ShardProducer[string], producers)
+	for p := range ps {
+		ps[p] = r.Producer()
+	}
+	assert.NotEqual(t, ps[0].Shard(), ps[1].Shard())
+	assert.Equal(t, ps[0].Shard(), ps[4].Shard())
+
+	var wg sync.WaitGroup
+	for p := range ps {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			key := strconv.Itoa(p)
+			for i := 0; i < n; i++ {
+				ps[p].Write(key + ":" + strconv.Itoa(i))
+			}
+		}(p)
+	}
+	wg.Wait()
+	assert.Equal(t, producers*n, r.Len())
+	assert.Equal(t, 2*n, r.ShardLen(ps[0].Shard()))
+
+	// ordered per producer
+	next := make(map[string]int)
+	for _, v := range r.DrainAll() {
+		key, idx, _ := strings.Cut(v, ":")
+		i, _ := strconv.Atoi(idx)
+		assert.Equal(t, next[key], i)
+		next[key]++
+	}
+	assert.Equal(t, producers, len(next))
+}
@@ -0,0 +1,186 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"sort"
+	"time"
+)
+
+// TimedRingOf is a ring buffer that stores a timestamp with each item.
+// Timestamps come from the clock (time.Now by default) and are kept monotonic,
+// so window queries can use binary search.
+// Items older than the TTL are dropped by Expire, and before each write.
+// It is not thread-safe(goroutine-safe).
+type TimedRingOf[T any] struct {
+	rb         *RingBufferOf[timedItem[T]]
+	ttl        time.Duration
+	last       time.Time
+	now        func() time.Time
+	onDiscards func(T)
+}
+
+type timedItem[T any] struct {
+	t time.Time
+	v T
+}
+
+// NewTimedRingOf creates a TimedRingOf, a ttl <= 0 means items never expire.
+// initialSize and maxBufferSize have the same meaning as in NewOf.
+func NewTimedRingOf[T any](initialSize int, ttl time.Duration, maxBufferSize ...int) *TimedRingOf[T] {
+	return &TimedRingOf[T]{
+		rb:  NewOf[timedItem[T]](initialSize, maxBufferSize...),
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// SetClock replaces the clock used to stamp written items, useful for tests.
+func (r *TimedRingOf[T]) SetClock(now func() time.Time) {
+	if now != nil {
+		r.now = now
+	}
+}
+
+// SetOnDiscards registers the callback for items discarded at maxSize or expired.
+func (r *TimedRingOf[T]) SetOnDiscards(fn func(T)) {
+	if fn == nil {
+		return
+	}
+	r.onDiscards = fn
+	r.rb.SetOnDiscards(func(it timedItem[T]) {
+		fn(it.v)
+	})
+}
+
+func (r *TimedRingOf[T]) Write(v T) {
+	r.rb.Write(r.stamp(v))
+}
+
+// Overwrite write, when the buffer reaches the maximum value, overwrite unread data.
+func (r *TimedRingOf[T]) Overwrite(v T) {
+	r.rb.Overwrite(r.stamp(v))
+}
+
+func (r *TimedRingOf[T]) stamp(v T) timedItem[T] {
+	now := r.now()
+	if now.Before(r.last) {
+		now = r.last
+	}
+	r.last = now
+	r.Expire(now)
+	return timedItem[T]{t: now, v: v}
+}
+
+func (r *TimedRingOf[T]) Read() (T, time.Time, error) {
+	it, err := r.rb.Read()
+	return it.v, it.t, err
+}
+
+func (r *TimedRingOf[T]) Peek() (T, time.Time, error) {
+	it, err := r.rb.Peek()
+	return it.v, it.t, err
+}
+
+// RPeek get the latest written data.
+func (r *TimedRingOf[T]) RPeek() (T, time.Time, error) {
+	it, err := r.rb.RPeek()
+	return it.v, it.t, err
+}
+
+// ReadSince reads all items, returns those written at or after t.
+// Older items are read and skipped, they are not counted in Discards.
+func (r *TimedRingOf[T]) ReadSince(t time.Time) []T {
+	skip, n := r.search(t), r.rb.Len()
+	if n == 0 {
+		return nil
+	}
+
+	var buf []T
+	if skip < n {
+		buf = make([]T, 0, n-skip)
+	}
+	for i := 0; i < n; i++ {
+		it, _ := r.rb.Read()
+		if i >= skip {
+			buf = append(buf, it.v)
+		}
+	}
+	return buf
+}
+
+// PeekRange returns the items written in [from, to), without reading them.
+func (r *TimedRingOf[T]) PeekRange(from, to time.Time) []T {
+	i, j := r.search(from), r.search(to)
+	if i >= j {
+		return nil
+	}
+
+	buf := make([]T, 0, j-i)
+	for ; i < j; i++ {
+		buf = append(buf, r.rb.at(i).v)
+	}
+	return buf
+}
+
+// Expire drops items older than the TTL at now, and returns how many were dropped.
+// Dropped items are counted in Discards and reported through the discard callback.
+func (r *TimedRingOf[T]) Expire(now time.Time) int {
+	if r.ttl <= 0 {
+		return 0
+	}
+	return r.discardBefore(r.search(now.Add(-r.ttl)))
+}
+
+// search returns the index of the first item written at or after t.
+func (r *TimedRingOf[T]) search(t time.Time) int {
+	return sort.Search(r.rb.Len(), func(i int) bool {
+		return !r.rb.at(i).t.Before(t)
+	})
+}
+
+func (r *TimedRingOf[T]) discardBefore(n int) int {
+	for i := 0; i < n; i++ {
+		it, _ := r.rb.Read()
+		r.rb.discards++
+		if r.onDiscards != nil {
+			r.onDiscards(it.v)
+		}
+	}
+	return n
+}
+
+// TTL returns the time to live of the items.
+func (r *TimedRingOf[T]) TTL() time.Duration {
+	return r.ttl
+}
+
+func (r *TimedRingOf[T]) IsEmpty() bool {
+	return r.rb.IsEmpty()
+}
+
+// Capacity returns the size of the underlying buffer.
+func (r *TimedRingOf[T]) Capacity() int {
+	return r.rb.Capacity()
+}
+
+func (r *TimedRingOf[T]) MaxSize() int {
+	return r.rb.MaxSize()
+}
+
+func (r *TimedRingOf[T]) Discards() uint64 {
+	return r.rb.Discards()
+}
+
+func (r *TimedRingOf[T]) Len() int {
+	return r.rb.Len()
+}
+
+func (r *TimedRingOf[T]) Reset() {
+	r.rb.Reset()
+}
+
+func (r *TimedRingOf[T]) SetMaxSize(n int) int {
+	return r.rb.SetMaxSize(n)
+}
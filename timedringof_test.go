@@ -0,0 +1,106 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestTimedRingOf(t *testing.T) {
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := base
+	rb := NewTimedRingOf[int](2, 5*time.Second)
+	rb.SetClock(func() time.Time {
+		return now
+	})
+	var discards []int
+	rb.SetOnDiscards(func(v int) {
+		discards = append(discards, v)
+	})
+
+	for i := 0; i < 5; i++ {
+		rb.Write(i)
+		now = now.Add(time.Second)
+	}
+	assert.Equal(t, 5, rb.Len())
+	assert.Equal(t, 8, rb.Capacity())
+
+	v, ts, err := rb.Peek()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, v)
+	assert.Equal(t, base, ts)
+
+	assert.Equal(t, []int{1, 2, 3}, rb.PeekRange(base.Add(time.Second), base.Add(4*time.Second)))
+	assert.Equal(t, []int{3, 4}, rb.PeekRange(base.Add(2500*time.Millisecond), now))
+	assert.Nil(t, rb.PeekRange(now, now.Add(time.Hour)))
+	assert.Nil(t, rb.PeekRange(base.Add(3*time.Second), base.Add(time.Second)))
+
+	// now is base+5s, items at base and earlier are older than the TTL
+	assert.Equal(t, 0, rb.Expire(now))
+	assert.Equal(t, 1, rb.Expire(now.Add(time.Second)))
+	assert.Equal(t, []int{0}, discards)
+	assert.Equal(t, uint64(1), rb.Discards())
+
+	// the clock going backwards keeps timestamps monotonic
+	now = base.Add(2 * time.Second)
+	rb.Write(5)
+	v, ts, err = rb.RPeek()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, v)
+	assert.Equal(t, base.Add(4*time.Second), ts)
+
+	// writes expire old items first
+	now = base.Add(8 * time.Second)
+	rb.Write(6)
+	assert.Equal(t, []int{0, 1, 2}, discards)
+	assert.Equal(t, 4, rb.Len())
+
+	// older items are skipped, only Expire reports discards
+	assert.Equal(t, []int{4, 5, 6}, rb.ReadSince(base.Add(4*time.Second)))
+	assert.Equal(t, []int{0, 1, 2}, discards)
+	assert.Equal(t, uint64(3), rb.Discards())
+	assert.True(t, rb.IsEmpty())
+	assert.Nil(t, rb.ReadSince(base))
+
+	rb.Write(7)
+	assert.Nil(t, rb.ReadSince(now.Add(time.Second)))
+	assert.True(t, rb.IsEmpty())
+	assert.Equal(t, uint64(3), rb.Discards())
+
+	_, _, err = rb.Read()
+	assert.Equal(t, ErrIsEmpty, err)
+}
+
+func TestTimedRingOf_MaxSize(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	rb := NewTimedRingOf[string](2, 0, 3)
+	rb.SetClock(func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	})
+
+	rb.Write("a")
+	rb.Write("b")
+	rb.Write("c")
+	rb.Write("d")
+	assert.Equal(t, 3, rb.Len())
+	assert.Equal(t, uint64(1), rb.Discards())
+
+	rb.Overwrite("e")
+	v, _, err := rb.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "b", v)
+
+	// ttl <= 0 never expires
+	assert.Equal(t, 0, rb.Expire(now.Add(time.Hour)))
+	assert.Equal(t, 2, rb.Len())
+	assert.Equal(t, time.Duration(0), rb.TTL())
+	assert.Equal(t, 3, rb.MaxSize())
+
+	rb.Reset()
+	assert.True(t, rb.IsEmpty())
+}
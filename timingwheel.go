@@ -0,0 +1,243 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TimerID identifies a timer scheduled on a TimingWheel.
+type TimerID uint64
+
+// TimingWheel is a hierarchical timing wheel for a large number of timers.
+// Each level has the same number of slots, a slot of level 0 covers one tick,
+// a slot of level i covers slots^i ticks. Slots are RingBufferOf buckets,
+// so Schedule and Cancel are O(1) (amortized), timers are cascaded to lower levels as time passes.
+// Cancelled timers are removed from their slot once they make up half of it.
+// The wheel is driven by Advance (or Tick), with a real or fake clock, or by Run.
+// Timer callbacks are called by the goroutine that advances the wheel, without the lock held.
+// It is thread-safe(goroutine-safe).
+type TimingWheel struct {
+	mu     sync.Mutex
+	tick   time.Duration
+	slots  int64
+	spans  []int64 // ticks covered by a whole level, slots^(i+1)
+	levels [][]*RingBufferOf[*wheelTimer]
+	dead   [][]int // cancelled timers still in each slot
+	timers map[TimerID]*wheelTimer
+	nextID TimerID
+	cur    int64 // ticks since start
+	start  time.Time
+	now    func() time.Time
+}
+
+type wheelTimer struct {
+	id        TimerID
+	expire    int64 // in ticks since start
+	fn        func()
+	cancelled bool
+	level     int // slot the timer is in
+	slot      int
+}
+
+// NewTimingWheel creates a TimingWheel of the given number of levels and slots per level,
+// each tick long. The longest delay without extra cascading is tick*slots^levels.
+func NewTimingWheel(tick time.Duration, slots, levels int) *TimingWheel {
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+	if slots < minBufferSize {
+		slots = minBufferSize
+	}
+	if levels < 1 {
+		levels = 1
+	}
+
+	w := &TimingWheel{
+		tick:   tick,
+		slots:  int64(slots),
+		spans:  make([]int64, levels),
+		levels: make([][]*RingBufferOf[*wheelTimer], levels),
+		dead:   make([][]int, levels),
+		timers: make(map[TimerID]*wheelTimer),
+		now:    time.Now,
+	}
+	span := int64(1)
+	for i := range w.levels {
+		span *= w.slots
+		w.spans[i] = span
+		w.levels[i] = make([]*RingBufferOf[*wheelTimer], slots)
+		w.dead[i] = make([]int, slots)
+		for j := range w.levels[i] {
+			w.levels[i][j] = NewUnboundedOf[*wheelTimer](minBufferSize)
+		}
+	}
+	w.start = w.now()
+	return w
+}
+
+// SetClock replaces time.Now used by Run, useful for tests.
+// If the wheel has not ticked yet, it restarts from the new clock.
+func (w *TimingWheel) SetClock(now func() time.Time) {
+	if now == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.now = now
+	if w.cur == 0 {
+		w.start = now()
+	}
+}
+
+// Schedule calls fn once, after d.
+func (w *TimingWheel) Schedule(d time.Duration, fn func()) TimerID {
+	ticks := int64((d + w.tick - 1) / w.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextID++
+	t := &wheelTimer{
+		id:     w.nextID,
+		expire: w.cur + ticks,
+		fn:     fn,
+	}
+	w.timers[t.id] = t
+	w.place(t)
+	return t.id
+}
+
+// Cancel stops the timer, it returns false if the timer has already fired or been cancelled.
+func (w *TimingWheel) Cancel(id TimerID) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	t, ok := w.timers[id]
+	if !ok {
+		return false
+	}
+	t.cancelled = true
+	t.fn = nil
+	delete(w.timers, id)
+
+	// compact the slot once half of it is cancelled
+	slot := w.levels[t.level][t.slot]
+	w.dead[t.level][t.slot]++
+	if w.dead[t.level][t.slot]*2 >= slot.Len() {
+		slot.RemoveFunc(func(t *wheelTimer) bool {
+			return t.cancelled
+		})
+		w.dead[t.level][t.slot] = 0
+	}
+	return true
+}
+
+// Len returns the number of pending timers.
+func (w *TimingWheel) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.timers)
+}
+
+// Tick advances the wheel by one tick, and calls the expired timers.
+func (w *TimingWheel) Tick() {
+	w.mu.Lock()
+	due := w.advance(nil)
+	w.mu.Unlock()
+	fire(due)
+}
+
+// Advance advances the wheel to now, and calls the expired timers.
+func (w *TimingWheel) Advance(now time.Time) {
+	w.mu.Lock()
+	var due []*wheelTimer
+	for target := int64(now.Sub(w.start) / w.tick); w.cur < target; {
+		due = w.advance(due)
+	}
+	w.mu.Unlock()
+	fire(due)
+}
+
+// Run advances the wheel every tick until ctx is done.
+func (w *TimingWheel) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			now := w.now()
+			w.mu.Unlock()
+			w.Advance(now)
+		}
+	}
+}
+
+// advance moves the wheel one tick forward, it appends the expired timers to due.
+func (w *TimingWheel) advance(due []*wheelTimer) []*wheelTimer {
+	w.cur++
+
+	// cascade the higher levels whose slot starts now
+	for i := 1; i < len(w.levels); i++ {
+		span := w.spans[i-1]
+		if w.cur%span != 0 {
+			break
+		}
+
+		j := int((w.cur / span) % w.slots)
+		slot := w.levels[i][j]
+		w.dead[i][j] = 0
+		for k := slot.Len(); k > 0; k-- {
+			t, _ := slot.Read()
+			if !t.cancelled {
+				w.place(t)
+			}
+		}
+	}
+
+	j := int(w.cur % w.slots)
+	slot := w.levels[0][j]
+	w.dead[0][j] = 0
+	for k := slot.Len(); k > 0; k-- {
+		t, _ := slot.Read()
+		if t.cancelled {
+			continue
+		}
+		if t.expire > w.cur {
+			// beyond the highest level, wait for another turn
+			w.place(t)
+			continue
+		}
+		delete(w.timers, t.id)
+		due = append(due, t)
+	}
+	return due
+}
+
+// place puts t into the slot of the lowest level that covers its expiration.
+func (w *TimingWheel) place(t *wheelTimer) {
+	delta := t.expire - w.cur
+	for i, span := range w.spans {
+		if delta < span || i == len(w.spans)-1 {
+			div := span / w.slots
+			t.level, t.slot = i, int((t.expire/div)%w.slots)
+			w.levels[i][t.slot].Write(t)
+			return
+		}
+	}
+}
+
+func fire(due []*wheelTimer) {
+	for _, t := range due {
+		t.fn()
+	}
+}
@@ -0,0 +1,211 @@
+//go:build go1.18
+// +build go1.18
+
+package ringbuffer
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fufuok/ringbuffer/internal/assert"
+)
+
+func TestTimingWheel(t *testing.T) {
+	clock := newFakeClock()
+	w := NewTimingWheel(time.Millisecond, 4, 2)
+	w.SetClock(clock.Now)
+	start := clock.Now()
+
+	var fired []int
+	schedule := func(d time.Duration, v int) TimerID {
+		return w.Schedule(d, func() {
+			fired = append(fired, v)
+		})
+	}
+
+	schedule(0, 0)
+	schedule(3*time.Millisecond, 3)
+	schedule(5*time.Millisecond, 5)
+	schedule(17*time.Millisecond, 17)
+	// beyond the highest level
+	schedule(40*time.Millisecond, 40)
+	id := schedule(10*time.Millisecond, 10)
+	assert.Equal(t, 6, w.Len())
+
+	w.Tick()
+	assert.Equal(t, []int{0}, fired)
+
+	assert.True(t, w.Cancel(id))
+	assert.False(t, w.Cancel(id))
+	assert.Equal(t, 4, w.Len())
+
+	w.Advance(start.Add(4 * time.Millisecond))
+	assert.Equal(t, []int{0, 3}, fired)
+	w.Advance(start.Add(5 * time.Millisecond))
+	assert.Equal(t, []int{0, 3, 5}, fired)
+	w.Advance(start.Add(16 * time.Millisecond))
+	assert.Equal(t, []int{0, 3, 5}, fired)
+	w.Advance(start.Add(17 * time.Millisecond))
+	assert.Equal(t, []int{0, 3, 5, 17}, fired)
+	w.Advance(start.Add(39 * time.Millisecond))
+	assert.Equal(t, []int{0, 3, 5, 17}, fired)
+	w.Advance(start.Add(40 * time.Millisecond))
+	assert.Equal(t, []int{0, 3, 5, 17, 40}, fired)
+	assert.Equal(t, 0, w.Len())
+
+	// scheduled relative to the current tick
+	schedule(2*time.Millisecond, 42)
+	w.Advance(start.Add(41 * time.Millisecond))
+	assert.Equal(t, 5, len(fired))
+	w.Advance(start.Add(42 * time.Millisecond))
+	assert.Equal(t, []int{0, 3, 5, 17, 40, 42}, fired)
+	w.Advance(start)
+	assert.Equal(t, 6, len(fired))
+}
+
+func TestTimingWheel_Random(t *testing.T) {
+	w := NewTimingWheel(time.Millisecond, 8, 3)
+	start := time.Now()
+	w.SetClock(func() time.Time {
+		return start
+	})
+
+	fired := make(map[int]int64)
+	expected := make(map[int]int64)
+	var now int64
+	for i := 0; i < 2000; i++ {
+		i := i
+		d := int64(i*7919) % 1500
+		w.Schedule(time.Duration(d)*time.Millisecond, func() {
+			fired[i] = now
+		})
+		if d == 0 {
+			d = 1
+		}
+		expected[i] = d
+	}
+
+	for now = 1; now <= 1500; now++ {
+		w.Tick()
+	}
+	assert.Equal(t, expected, fired)
+}
+
+func TestTimingWheel_Cancel(t *testing.T) {
+	w := NewTimingWheel(time.Millisecond, 8, 2)
+	pending := func() (n int) {
+		for _, level := range w.levels {
+			for _, slot := range level {
+				n += slot.Len()
+			}
+		}
+		return
+	}
+
+	var fired int
+	ids := make([]TimerID, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		ids = append(ids, w.Schedule(time.Duration(i%100)*time.Millisecond, func() {
+			fired++
+		}))
+	}
+	assert.Equal(t, 10000, pending())
+
+	// cancelled timers release their callback and are removed from their slots
+	tm := w.timers[ids[0]]
+	for _, id := range ids[:9990] {
+		assert.True(t, w.Cancel(id))
+	}
+	assert.True(t, tm.fn == nil)
+	assert.Equal(t, 10, w.Len())
+	assert.True(t, pending() < 100)
+
+	for _, id := range ids[9990:] {
+		assert.True(t, w.Cancel(id))
+	}
+	assert.Equal(t, 0, w.Len())
+	assert.Equal(t, 0, pending())
+	for i := 0; i < 100; i++ {
+		w.Tick()
+	}
+	assert.Equal(t, 0, fired)
+}
+
+func TestTimingWheel_Run(t *testing.T) {
+	w := NewTimingWheel(time.Millisecond, 16, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	done := make(chan struct{})
+	w.Schedule(5*time.Millisecond, func() {
+		close(done)
+	})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timer did not fire")
+	}
+}
+
+func BenchmarkTimingWheel_Schedule(b *testing.B) {
+	w := NewTimingWheel(time.Millisecond, 256, 4)
+	fn := func() {}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Schedule(time.Duration(i%60000)*time.Millisecond, fn)
+	}
+}
+
+func BenchmarkTimingWheel_ScheduleCancel(b *testing.B) {
+	w := NewTimingWheel(time.Millisecond, 256, 4)
+	fn := func() {}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Cancel(w.Schedule(time.Duration(i%60000)*time.Millisecond, fn))
+	}
+}
+
+func BenchmarkTimingWheel_Tick(b *testing.B) {
+	w := NewTimingWheel(time.Millisecond, 256, 4)
+	var n int64
+	fn := func() {
+		atomic.AddInt64(&n, 1)
+	}
+	for i := 0; i < 100000; i++ {
+		w.Schedule(time.Duration(i%1000)*time.Millisecond, fn)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Schedule(time.Duration(i%1000)*time.Millisecond, fn)
+		w.Tick()
+	}
+}
+
+func BenchmarkAfterFunc(b *testing.B) {
+	fn := func() {}
+	timers := make([]*time.Timer, 0, b.N)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		timers = append(timers, time.AfterFunc(time.Duration(i%60000)*time.Millisecond+time.Minute, fn))
+	}
+	b.StopTimer()
+	for _, t := range timers {
+		t.Stop()
+	}
+}
+
+func BenchmarkAfterFunc_Stop(b *testing.B) {
+	fn := func() {}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		time.AfterFunc(time.Duration(i%60000)*time.Millisecond, fn).Stop()
+	}
+}